@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image/png"
+	"os"
+	"sort"
+	"time"
+
+	"unholy-cad/dxf"
+	"unholy-cad/render"
+	"unholy-cad/sketch"
+	"unholy-cad/svg"
+	"unholy-cad/view"
+)
+
+// exit codes of the command line tools
+const (
+	exitSuccess      = 0
+	exitUnsolvable   = 1
+	exitInvalidInput = 2
+)
+
+// subcommands run instead of the editor when they are the first argument. They don't
+// need a display, builds with the headless tag only contain them
+var commands = map[string]func(args []string) int{
+	"solve":   runSolve,
+	"svg":     runSVG,
+	"dxf":     runDXF,
+	"import":  runImport,
+	"render":  runRender,
+	"measure": runMeasure,
+}
+
+// parseArgs parses flags that may come before, between or after the positional arguments
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// solveReport is printed by the solve command
+type solveReport struct {
+	Status      string         `json:"status"`
+	Satisfied   []int          `json:"satisfied"`
+	Unsatisfied []int          `json:"unsatisfied"`
+	Invalid     map[int]string `json:"invalid"`
+	Redundant   []int          `json:"redundant"`
+	Conflicting []int          `json:"conflicting"`
+	Attempts    int            `json:"attempts"`
+	Seeds       int            `json:"seeds"`
+	Flips       int            `json:"flips"`
+	Dof         int            `json:"dof"`
+	SolveMs     float64        `json:"solveMs"`
+}
+
+// runSolve loads a sketch, solves it and prints a report. The solved sketch is written
+// when an output path is given and every constraint is satisfied
+func runSolve(args []string) int {
+	flags := flag.NewFlagSet("solve", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: unholy-cad solve [-o out.json] [-v] in.json")
+		flags.PrintDefaults()
+	}
+	outPath := flags.String("o", "", "file to write the solved sketch to")
+	verbose := flags.Bool("v", false, "print the solver progress to stderr")
+	paths, err := parseArgs(flags, args)
+	if err != nil {
+		return exitInvalidInput
+	}
+	if len(paths) != 1 {
+		flags.Usage()
+		return exitInvalidInput
+	}
+	s, err := sketch.Load(paths[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+
+	start := time.Now()
+	result, err := s.AttemptApplyConstraints()
+	elapsed := time.Since(start)
+	if *verbose {
+		for id, invalidErr := range result.Invalid {
+			fmt.Fprintf(os.Stderr, "constraint %d is invalid: %v\n", id, invalidErr)
+		}
+		fmt.Fprintln(os.Stderr, result)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+	analysis, err := s.AnalyzeDegreesOfFreedom()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+
+	report := solveReport{
+		Status:      "solved",
+		Satisfied:   make([]int, 0),
+		Unsatisfied: make([]int, 0),
+		Invalid:     map[int]string{},
+		Redundant:   analysis.Redundant,
+		Conflicting: analysis.Conflicting,
+		Attempts:    result.Attempts,
+		Seeds:       result.Seeds,
+		Flips:       result.Flips,
+		Dof:         analysis.Dof,
+		SolveMs:     float64(elapsed.Microseconds()) / 1000,
+	}
+	for id, err := range result.Invalid {
+		report.Invalid[id] = err.Error()
+	}
+	for _, constraint := range s.GetConstraints() {
+		id := constraint.GetId()
+		if _, invalid := result.Invalid[id]; invalid {
+			continue
+		}
+		if d, ok := constraint.(sketch.SketchDimension); ok && d.IsReference() {
+			continue
+		}
+		if satisfied, err := constraint.IsSatisfied(s); err == nil && satisfied {
+			report.Satisfied = append(report.Satisfied, id)
+		} else {
+			report.Unsatisfied = append(report.Unsatisfied, id)
+		}
+	}
+	sort.Ints(report.Satisfied)
+	sort.Ints(report.Unsatisfied)
+
+	code := exitSuccess
+	switch {
+	case len(result.Invalid) > 0:
+		report.Status = "invalid"
+		code = exitInvalidInput
+	case !result.Satisfied:
+		report.Status = "unsolvable"
+		code = exitUnsolvable
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+	fmt.Println(string(data))
+
+	if *outPath != "" && code == exitSuccess {
+		if err := s.Save(*outPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitInvalidInput
+		}
+	}
+	return code
+}
+
+// runSVG exports a sketch as it is, without solving it first
+func runSVG(args []string) int {
+	flags := flag.NewFlagSet("svg", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: unholy-cad svg [-o out.svg] [-constraints=false] [-grid] [-construction] [-scale px] in.json")
+		flags.PrintDefaults()
+	}
+	outPath := flags.String("o", "", "file to write the drawing to instead of stdout")
+	options := svg.DefaultOptions
+	flags.BoolVar(&options.Constraints, "constraints", options.Constraints, "draw constraints and dimensions")
+	flags.BoolVar(&options.Grid, "grid", options.Grid, "draw the grid")
+	flags.BoolVar(&options.Construction, "construction", options.Construction, "draw the origin and the axes")
+	flags.Float64Var(&options.Scale, "scale", 0, "pixels per unit the annotations are sized for, 0 fits the sketch")
+	paths, err := parseArgs(flags, args)
+	if err != nil {
+		return exitInvalidInput
+	}
+	if len(paths) != 1 {
+		flags.Usage()
+		return exitInvalidInput
+	}
+
+	s, err := sketch.Load(paths[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+	data, err := svg.Export(s, options)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+	return writeOutput(*outPath, data)
+}
+
+// runDXF exports the geometry of a sketch for cutters and other CAD programs
+func runDXF(args []string) int {
+	flags := flag.NewFlagSet("dxf", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: unholy-cad dxf [-o out.dxf] [-r12] [-polylines] in.json")
+		flags.PrintDefaults()
+	}
+	outPath := flags.String("o", "", "file to write the drawing to instead of stdout")
+	r12 := flags.Bool("r12", false, "write DXF R12 instead of R2000")
+	options := dxf.Options{Version: dxf.R2000}
+	flags.BoolVar(&options.Polylines, "polylines", false, "join connected lines and arcs into polylines, R2000 only")
+	paths, err := parseArgs(flags, args)
+	if err != nil {
+		return exitInvalidInput
+	}
+	if len(paths) != 1 {
+		flags.Usage()
+		return exitInvalidInput
+	}
+	if *r12 {
+		options.Version = dxf.R12
+	}
+
+	s, err := sketch.Load(paths[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+	data, err := dxf.Export(s, options)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+	return writeOutput(*outPath, data)
+}
+
+// runImport converts a DXF drawing into a sketch without constraints
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: unholy-cad import [-o out.json] [-tolerance distance] in.dxf")
+		flags.PrintDefaults()
+	}
+	outPath := flags.String("o", "", "file to write the sketch to instead of stdout")
+	tolerance := flags.Float64("tolerance", dxf.DefaultTolerance, "distance within which endpoints are merged")
+	paths, err := parseArgs(flags, args)
+	if err != nil {
+		return exitInvalidInput
+	}
+	if len(paths) != 1 {
+		flags.Usage()
+		return exitInvalidInput
+	}
+
+	file, err := os.Open(paths[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+	defer file.Close()
+	s, err := dxf.Import(file, *tolerance)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+	return writeOutput(*outPath, append(data, '\n'))
+}
+
+// runRender draws a sketch into a PNG image the way the editor shows it, without
+// opening a window
+func runRender(args []string) int {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: unholy-cad render [-o out.png] [-width px] [-height px] [-scale px -x left -y top] [-grid=false] [-constraints=false] [-construction=false] in.json")
+		flags.PrintDefaults()
+	}
+	outPath := flags.String("o", "", "file to write the image to instead of stdout")
+	width := flags.Int("width", screenWidth, "width of the image in pixels")
+	height := flags.Int("height", screenHeight, "height of the image in pixels")
+	camera := view.Camera{PixelSnap: true}
+	flags.Float64Var(&camera.Scale, "scale", 0, "pixels per unit, 0 fits the sketch into the image")
+	flags.Float64Var(&camera.Position.X, "x", 0, "world position at the left edge, used with -scale")
+	flags.Float64Var(&camera.Position.Y, "y", 0, "world position at the top edge, used with -scale")
+	options := view.DefaultOptions
+	flags.BoolVar(&options.Grid, "grid", options.Grid, "draw the grid")
+	flags.BoolVar(&options.Constraints, "constraints", options.Constraints, "draw constraints and dimensions")
+	flags.BoolVar(&options.Construction, "construction", options.Construction, "draw the origin and the axes")
+	paths, err := parseArgs(flags, args)
+	if err != nil {
+		return exitInvalidInput
+	}
+	if len(paths) != 1 || *width <= 0 || *height <= 0 {
+		flags.Usage()
+		return exitInvalidInput
+	}
+
+	s, err := sketch.Load(paths[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+	if camera.Scale <= 0 {
+		camera = view.FitCamera(s, float64(*width), float64(*height), 40)
+		camera.PixelSnap = true
+	}
+
+	r := view.NewRenderer(s, camera, float64(*width), float64(*height))
+	r.Options = options
+	if dof, err := s.AnalyzeDegreesOfFreedom(); err == nil {
+		r.Dof = dof
+	}
+	img, err := render.Render(r)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+	var data bytes.Buffer
+	if err := png.Encode(&data, img); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+	return writeOutput(*outPath, data.Bytes())
+}
+
+// measureReport is printed by the measure command
+type measureReport struct {
+	Regions    []regionReport `json:"regions"`
+	OpenChains [][]int        `json:"openChains"`
+	Dangling   []int          `json:"dangling"`
+}
+
+// regionReport is a region as the ids of its boundary elements and its section properties
+type regionReport struct {
+	Outer []int   `json:"outer"`
+	Holes [][]int `json:"holes"`
+	sketch.SectionProperties
+}
+
+func loopIds(loop sketch.Loop) []int {
+	ids := make([]int, len(loop.Edges))
+	for i, edge := range loop.Edges {
+		ids[i] = edge.Id
+	}
+	return ids
+}
+
+// runMeasure prints the section properties of every closed region of a sketch as it is,
+// without solving it first
+func runMeasure(args []string) int {
+	flags := flag.NewFlagSet("measure", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: unholy-cad measure [-o out.json] in.json")
+		flags.PrintDefaults()
+	}
+	outPath := flags.String("o", "", "file to write the report to instead of stdout")
+	paths, err := parseArgs(flags, args)
+	if err != nil {
+		return exitInvalidInput
+	}
+	if len(paths) != 1 {
+		flags.Usage()
+		return exitInvalidInput
+	}
+
+	s, err := sketch.Load(paths[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+	topology, err := s.AnalyzeTopology()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+
+	report := measureReport{
+		Regions:    make([]regionReport, 0, len(topology.Regions)),
+		OpenChains: make([][]int, 0),
+		Dangling:   make([]int, 0),
+	}
+	for _, region := range topology.Regions {
+		properties, err := region.Measure(s)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitInvalidInput
+		}
+		holes := make([][]int, len(region.Holes))
+		for i, hole := range region.Holes {
+			holes[i] = loopIds(hole)
+		}
+		report.Regions = append(report.Regions, regionReport{Outer: loopIds(region.Outer), Holes: holes, SectionProperties: properties})
+	}
+	report.OpenChains = append(report.OpenChains, topology.OpenChains...)
+	report.Dangling = append(report.Dangling, topology.Dangling...)
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+	return writeOutput(*outPath, append(data, '\n'))
+}
+
+// writeOutput writes the result of a command to a file, or to stdout without a path
+func writeOutput(path string, data []byte) int {
+	if path == "" {
+		os.Stdout.Write(data)
+		return exitSuccess
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidInput
+	}
+	return exitSuccess
+}
@@ -0,0 +1,334 @@
+//go:build !headless
+
+package main
+
+import (
+	"log"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"unholy-cad/sketch"
+)
+
+// selectedPoints returns the selected points, or false when anything else is selected
+func (g *Game) selectedPoints() ([]*sketch.SketchPoint, bool) {
+	points := make([]*sketch.SketchPoint, 0, len(g.selection))
+	for _, id := range g.selection {
+		point, err := sketch.GetElementByID[*sketch.SketchPoint](&g.sketch, id)
+		if err != nil {
+			return nil, false
+		}
+		points = append(points, point)
+	}
+	return points, true
+}
+
+// selectedLines returns the selected lines, or false when anything else is selected
+func (g *Game) selectedLines() ([]*sketch.SketchLine, bool) {
+	lines := make([]*sketch.SketchLine, 0, len(g.selection))
+	for _, id := range g.selection {
+		line, err := sketch.GetElementByID[*sketch.SketchLine](&g.sketch, id)
+		if err != nil {
+			return nil, false
+		}
+		lines = append(lines, line)
+	}
+	return lines, true
+}
+
+// selectedCurves returns the selected circles and arcs, or false when anything else is selected
+func (g *Game) selectedCurves() ([]sketch.SketchCurve, bool) {
+	curves := make([]sketch.SketchCurve, 0, len(g.selection))
+	for _, id := range g.selection {
+		curve, err := sketch.GetCurve(&g.sketch, id)
+		if err != nil {
+			return nil, false
+		}
+		curves = append(curves, curve)
+	}
+	return curves, true
+}
+
+// selectionByKind splits the selection into points, lines and curves
+func (g *Game) selectionByKind() ([]*sketch.SketchPoint, []*sketch.SketchLine, []sketch.SketchCurve) {
+	var points []*sketch.SketchPoint
+	var lines []*sketch.SketchLine
+	var curves []sketch.SketchCurve
+	for _, id := range g.selection {
+		element, err := sketch.GetElementByID[sketch.SketchElement](&g.sketch, id)
+		if err != nil {
+			continue
+		}
+		switch e := element.(type) {
+		case *sketch.SketchPoint:
+			points = append(points, e)
+		case *sketch.SketchLine:
+			lines = append(lines, e)
+		case sketch.SketchCurve:
+			curves = append(curves, e)
+		}
+	}
+	return points, lines, curves
+}
+
+// pointPair is two points an alignment constraint applies to, with the line they are
+// the endpoints of when a line was selected
+type pointPair struct {
+	point1Id, point2Id int
+	lineId             *int
+}
+
+// selectedPointPairs returns the point pairs an alignment constraint applies to:
+// the endpoints of every selected line, or two selected points
+func (g *Game) selectedPointPairs() []pointPair {
+	if points, ok := g.selectedPoints(); ok && len(points) == 2 {
+		return []pointPair{{point1Id: points[0].Id, point2Id: points[1].Id}}
+	}
+	if lines, ok := g.selectedLines(); ok && len(lines) > 0 {
+		pairs := make([]pointPair, len(lines))
+		for i, line := range lines {
+			lineId := line.Id
+			pairs[i] = pointPair{point1Id: line.StartId, point2Id: line.EndId, lineId: &lineId}
+		}
+		return pairs
+	}
+	return nil
+}
+
+// addConstraints adds constraints built from the selection as one undo step and solves the sketch
+func (g *Game) addConstraints(label string, constraints []sketch.SketchConstraint) {
+	if len(constraints) == 0 {
+		log.Printf("%s: selection does not fit", label)
+		return
+	}
+	g.edit(label, func() {
+		for _, constraint := range constraints {
+			g.sketch.Elements = append(g.sketch.Elements, constraint)
+		}
+		g.solve()
+	})
+	g.selection = nil
+}
+
+// updateConstraintHotkeys creates constraints from the current selection
+func (g *Game) updateConstraintHotkeys() {
+	nextId := g.sketch.NextId()
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyC) {
+		constraints := []sketch.SketchConstraint{}
+		if points, ok := g.selectedPoints(); ok && len(points) == 2 {
+			constraints = append(constraints, &sketch.SketchConstraintCoincident{Id: nextId, Point1Id: points[0].Id, Point2Id: points[1].Id})
+		}
+		g.addConstraints("Coincident", constraints)
+	}
+	// shift+h and shift+v dimension the horizontal or vertical distance between two points
+	shift := ebiten.IsKeyPressed(ebiten.KeyShift)
+	if shift && (inpututil.IsKeyJustPressed(ebiten.KeyH) || inpututil.IsKeyJustPressed(ebiten.KeyV)) {
+		direction := sketch.DistanceHorizontal
+		if inpututil.IsKeyJustPressed(ebiten.KeyV) {
+			direction = sketch.DistanceVertical
+		}
+		constraints := []sketch.SketchConstraint{}
+		if points, ok := g.selectedPoints(); ok && len(points) == 2 {
+			constraints = append(constraints, g.newPointDistance(nextId, points[0], points[1], direction))
+		}
+		g.addConstraints("Distance", constraints)
+	}
+	if !shift && inpututil.IsKeyJustPressed(ebiten.KeyH) {
+		constraints := []sketch.SketchConstraint{}
+		for i, pair := range g.selectedPointPairs() {
+			constraints = append(constraints, &sketch.SketchConstraintHorizontal{Id: nextId + i, Point1Id: pair.point1Id, Point2Id: pair.point2Id, LineId: pair.lineId})
+		}
+		g.addConstraints("Horizontal", constraints)
+	}
+	if !shift && inpututil.IsKeyJustPressed(ebiten.KeyV) {
+		constraints := []sketch.SketchConstraint{}
+		for i, pair := range g.selectedPointPairs() {
+			constraints = append(constraints, &sketch.SketchConstraintVertical{Id: nextId + i, Point1Id: pair.point1Id, Point2Id: pair.point2Id, LineId: pair.lineId})
+		}
+		g.addConstraints("Vertical", constraints)
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+		constraints := []sketch.SketchConstraint{}
+		if lines, ok := g.selectedLines(); ok && len(lines) == 2 {
+			constraints = append(constraints, &sketch.SketchConstraintParallel{Id: nextId, Line1Id: lines[0].Id, Line2Id: lines[1].Id})
+		}
+		g.addConstraints("Parallel", constraints)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyN) {
+		constraints := []sketch.SketchConstraint{}
+		if lines, ok := g.selectedLines(); ok && len(lines) == 2 {
+			constraints = append(constraints, &sketch.SketchConstraintPerpendicular{Id: nextId, Line1Id: lines[0].Id, Line2Id: lines[1].Id})
+		}
+		g.addConstraints("Perpendicular", constraints)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyA) {
+		constraints := []sketch.SketchConstraint{}
+		if lines, ok := g.selectedLines(); ok && len(lines) == 2 {
+			angle := &sketch.SketchConstraintLineAngle{Id: nextId, Line1Id: lines[0].Id, Line2Id: lines[1].Id}
+			// start from the current reading rounded to whole degrees
+			if current, err := angle.GetCurrentAngle(&g.sketch); err == nil {
+				angle.Angle = math.Mod(math.Round(current), 360)
+			}
+			constraints = append(constraints, angle)
+		}
+		g.addConstraints("Angle", constraints)
+	}
+
+	// r adds a radius and shift+r a diameter to every selected circle and arc
+	if inpututil.IsKeyJustPressed(ebiten.KeyR) {
+		diameter := ebiten.IsKeyPressed(ebiten.KeyShift)
+		constraints := []sketch.SketchConstraint{}
+		if curves, ok := g.selectedCurves(); ok {
+			for i, curve := range curves {
+				radius, err := curve.GetRadius(&g.sketch)
+				if err != nil {
+					continue
+				}
+				if diameter {
+					constraints = append(constraints, &sketch.SketchConstraintDiameter{Id: nextId + i, CurveId: curve.GetId(), Diameter: roundDimension(2 * radius)})
+				} else {
+					constraints = append(constraints, &sketch.SketchConstraintRadius{Id: nextId + i, CurveId: curve.GetId(), Radius: roundDimension(radius)})
+				}
+			}
+		}
+		if diameter {
+			g.addConstraints("Diameter", constraints)
+		} else {
+			g.addConstraints("Radius", constraints)
+		}
+	}
+
+	// t makes a line tangent to a curve, or joins two arcs smoothly at their shared point
+	if inpututil.IsKeyJustPressed(ebiten.KeyT) {
+		constraints := []sketch.SketchConstraint{}
+		points, lines, curves := g.selectionByKind()
+		if len(points) == 0 && len(lines) == 1 && len(curves) == 1 {
+			constraints = append(constraints, &sketch.SketchConstraintTangent{Id: nextId, LineId: lines[0].Id, CurveId: curves[0].GetId()})
+		} else if len(points) == 0 && len(lines) == 0 && len(curves) == 2 {
+			arc1, ok1 := curves[0].(*sketch.SketchArc)
+			arc2, ok2 := curves[1].(*sketch.SketchArc)
+			if ok1 && ok2 {
+				constraints = append(constraints, &sketch.SketchConstraintArcTangent{Id: nextId, Arc1Id: arc1.Id, Arc2Id: arc2.Id})
+			}
+		}
+		g.addConstraints("Tangent", constraints)
+	}
+
+	// o puts a point on a line or a curve, shift+o makes two curves concentric
+	if inpututil.IsKeyJustPressed(ebiten.KeyO) {
+		constraints := []sketch.SketchConstraint{}
+		points, lines, curves := g.selectionByKind()
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			if len(points) == 0 && len(lines) == 0 && len(curves) == 2 {
+				constraints = append(constraints, &sketch.SketchConstraintConcentric{Id: nextId, Curve1Id: curves[0].GetId(), Curve2Id: curves[1].GetId()})
+			}
+			g.addConstraints("Concentric", constraints)
+		} else {
+			if len(points) == 1 && len(lines) == 1 && len(curves) == 0 {
+				constraints = append(constraints, &sketch.SketchConstraintPointOnLine{Id: nextId, PointId: points[0].Id, LineId: lines[0].Id})
+			} else if len(points) == 1 && len(lines) == 0 && len(curves) == 1 {
+				constraints = append(constraints, &sketch.SketchConstraintPointOnCurve{Id: nextId, PointId: points[0].Id, CurveId: curves[0].GetId()})
+			}
+			g.addConstraints("Point on", constraints)
+		}
+	}
+
+	// s mirrors two points about a line
+	if inpututil.IsKeyJustPressed(ebiten.KeyS) {
+		constraints := []sketch.SketchConstraint{}
+		points, lines, curves := g.selectionByKind()
+		if len(points) == 2 && len(lines) == 1 && len(curves) == 0 {
+			constraints = append(constraints, &sketch.SketchConstraintSymmetric{Id: nextId, Point1Id: points[0].Id, Point2Id: points[1].Id, LineId: lines[0].Id})
+		}
+		g.addConstraints("Symmetric", constraints)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyM) {
+		constraints := []sketch.SketchConstraint{}
+		points, lines, curves := g.selectionByKind()
+		if len(points) == 1 && len(lines) == 1 && len(curves) == 0 {
+			constraints = append(constraints, &sketch.SketchConstraintMidpoint{Id: nextId, PointId: points[0].Id, LineId: lines[0].Id})
+		}
+		g.addConstraints("Midpoint", constraints)
+	}
+
+	// e makes every selected line or every selected curve the same size as the first one
+	if inpututil.IsKeyJustPressed(ebiten.KeyE) {
+		constraints := []sketch.SketchConstraint{}
+		points, lines, curves := g.selectionByKind()
+		ids := []int{}
+		if len(points) == 0 && len(curves) == 0 {
+			for _, line := range lines {
+				ids = append(ids, line.Id)
+			}
+		} else if len(points) == 0 && len(lines) == 0 {
+			for _, curve := range curves {
+				ids = append(ids, curve.GetId())
+			}
+		}
+		for i := 1; i < len(ids); i++ {
+			constraints = append(constraints, &sketch.SketchConstraintEqual{Id: nextId + i - 1, Element1Id: ids[0], Element2Id: ids[i]})
+		}
+		g.addConstraints("Equal", constraints)
+	}
+
+	// f fixes every selected point where it is now
+	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
+		constraints := []sketch.SketchConstraint{}
+		if points, ok := g.selectedPoints(); ok {
+			for _, point := range points {
+				if sketch.IsBuiltin(point.Id) {
+					continue
+				}
+				constraints = append(constraints, &sketch.SketchConstraintFixed{Id: nextId + len(constraints), PointId: point.Id, Position: point.Position})
+			}
+		}
+		g.addConstraints("Fix", constraints)
+	}
+
+	// d dimensions the length of a line, the distance between two points or from a point to a line.
+	// shift+d adds the same dimension as a reference that only shows the measurement
+	if inpututil.IsKeyJustPressed(ebiten.KeyD) {
+		constraints := []sketch.SketchConstraint{}
+		points, lines, curves := g.selectionByKind()
+		if len(points) == 0 && len(lines) == 1 && len(curves) == 0 {
+			if startPoint, endPoint, err := sketch.GetLinePoints(&g.sketch, lines[0].Id); err == nil {
+				length := roundDimension(startPoint.Position.DistanceTo(endPoint.Position))
+				constraints = append(constraints, &sketch.SketchConstraintLineLength{Id: nextId, LineId: lines[0].Id, Length: length})
+			}
+		} else if len(points) == 2 && len(lines) == 0 && len(curves) == 0 {
+			constraints = append(constraints, g.newPointDistance(nextId, points[0], points[1], sketch.DistanceAligned))
+		} else if len(points) == 1 && len(lines) == 1 && len(curves) == 0 {
+			distance := &sketch.SketchConstraintPointLineDistance{Id: nextId, PointId: points[0].Id, LineId: lines[0].Id}
+			if point, foot, err := distance.GetFoot(&g.sketch); err == nil {
+				distance.Distance = roundDimension(point.DistanceTo(foot))
+			}
+			constraints = append(constraints, distance)
+		}
+		if shift {
+			for _, constraint := range constraints {
+				constraint.(sketch.SketchDimension).SetReference(true)
+			}
+			g.addConstraints("Reference distance", constraints)
+		} else {
+			g.addConstraints("Distance", constraints)
+		}
+	}
+}
+
+// newPointDistance dimensions two points at their current distance
+func (g *Game) newPointDistance(id int, point1, point2 *sketch.SketchPoint, direction sketch.DistanceDirection) *sketch.SketchConstraintPointDistance {
+	distance := &sketch.SketchConstraintPointDistance{Id: id, Point1Id: point1.Id, Point2Id: point2.Id, Direction: direction}
+	if current, err := distance.GetCurrentDistance(&g.sketch); err == nil {
+		distance.Distance = roundDimension(current)
+	}
+	return distance
+}
+
+// roundDimension rounds a measured length to two decimals for a new dimension
+func roundDimension(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,340 @@
+// Package dxf reads and writes sketch geometry as DXF drawings. DXF has y pointing up,
+// so y is flipped both ways, which also turns clockwise arcs counter clockwise
+package dxf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"unholy-cad/geom"
+	"unholy-cad/sketch"
+)
+
+// Version is the DXF release written by Export
+type Version int
+
+const (
+	R12 Version = iota
+	R2000
+)
+
+// Options select how the geometry is written
+type Options struct {
+	Version Version
+	// join connected lines and arcs into LWPOLYLINE entities, only available in R2000
+	Polylines bool
+}
+
+// writer writes group code and value pairs
+type writer struct {
+	bytes.Buffer
+	version Version
+	handle  int
+}
+
+func (w *writer) pair(code int, value string) {
+	fmt.Fprintf(&w.Buffer, "%3d\n%s\n", code, value)
+}
+
+func (w *writer) number(code int, value float64) {
+	w.pair(code, formatNumber(value))
+}
+
+// point writes a sketch position with x and y on consecutive group codes
+func (w *writer) point(code int, p geom.Vec2) {
+	w.number(code, p.X)
+	w.number(code+10, -p.Y)
+}
+
+// entity starts an entity. R2000 entities need a handle and their subclass markers
+func (w *writer) entity(kind string, subclasses ...string) {
+	w.pair(0, kind)
+	if w.version == R12 {
+		w.pair(8, "0")
+		return
+	}
+	w.handle++
+	w.pair(5, strconv.FormatInt(int64(w.handle), 16))
+	w.pair(100, "AcDbEntity")
+	w.pair(8, "0")
+	for _, subclass := range subclasses {
+		w.pair(100, subclass)
+	}
+}
+
+// formatNumber writes a real value, always with a decimal point
+func formatNumber(v float64) string {
+	v = math.Round(v*1e9) / 1e9
+	if v == 0 {
+		// no negative zero
+		v = 0
+	}
+	text := strconv.FormatFloat(v, 'f', -1, 64)
+	if !strings.Contains(text, ".") {
+		text += ".0"
+	}
+	return text
+}
+
+// degrees converts a sketch angle to a DXF angle, which turns the other way
+func degrees(angle float64) float64 {
+	d := math.Mod(-angle*180/math.Pi, 360)
+	if d < 0 {
+		d += 360
+	}
+	return d
+}
+
+// Export writes the points, lines, circles and arcs of a sketch. Points are only written
+// when no line or curve uses them. The origin and axes are left out
+func Export(s *sketch.Sketch, options Options) ([]byte, error) {
+	if options.Polylines && options.Version != R2000 {
+		return nil, fmt.Errorf("polylines need DXF R2000")
+	}
+
+	w := &writer{version: options.Version}
+	w.pair(0, "SECTION")
+	w.pair(2, "HEADER")
+	w.pair(9, "$ACADVER")
+	if options.Version == R2000 {
+		w.pair(1, "AC1015")
+		w.pair(9, "$INSUNITS")
+		// millimeters
+		w.pair(70, "4")
+	} else {
+		w.pair(1, "AC1009")
+	}
+	w.pair(0, "ENDSEC")
+
+	w.pair(0, "SECTION")
+	w.pair(2, "ENTITIES")
+
+	used := map[int]bool{}
+	segments := make([]sketch.SketchElement, 0)
+	for _, element := range s.Elements {
+		if sketch.IsBuiltin(element.GetId()) {
+			continue
+		}
+		switch element.(type) {
+		case *sketch.SketchLine, *sketch.SketchArc:
+			segments = append(segments, element)
+		case *sketch.SketchCircle:
+		default:
+			continue
+		}
+		for _, id := range element.GetReferences() {
+			used[id] = true
+		}
+	}
+
+	for _, element := range s.Elements {
+		if sketch.IsBuiltin(element.GetId()) {
+			continue
+		}
+		switch e := element.(type) {
+		case *sketch.SketchPoint:
+			if !used[e.Id] {
+				w.entity("POINT", "AcDbPoint")
+				w.point(10, e.Position)
+			}
+		case *sketch.SketchCircle:
+			center, err := e.GetCenter(s)
+			if err != nil {
+				return nil, err
+			}
+			w.entity("CIRCLE", "AcDbCircle")
+			w.point(10, center.Position)
+			w.number(40, e.Radius)
+		}
+	}
+
+	if options.Polylines {
+		for _, chain := range chainSegments(segments) {
+			if err := w.polyline(s, chain); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		for _, segment := range segments {
+			if err := w.segment(s, segment); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	w.pair(0, "ENDSEC")
+	w.pair(0, "EOF")
+	return w.Bytes(), nil
+}
+
+// segment writes a line as LINE and an arc as ARC
+func (w *writer) segment(s *sketch.Sketch, segment sketch.SketchElement) error {
+	switch e := segment.(type) {
+	case *sketch.SketchLine:
+		start, end, err := sketch.GetLinePoints(s, e.Id)
+		if err != nil {
+			return err
+		}
+		w.entity("LINE", "AcDbLine")
+		w.point(10, start.Position)
+		w.point(11, end.Position)
+	case *sketch.SketchArc:
+		center, _, _, err := e.GetPoints(s)
+		if err != nil {
+			return err
+		}
+		radius, err := e.GetRadius(s)
+		if err != nil {
+			return err
+		}
+		start, sweep, err := e.GetAngles(s)
+		if err != nil {
+			return err
+		}
+		w.entity("ARC", "AcDbCircle")
+		w.point(10, center.Position)
+		w.number(40, radius)
+		if w.version == R2000 {
+			w.pair(100, "AcDbArc")
+		}
+		// flipped, the arc runs counter clockwise from the end point to the start point
+		w.number(50, degrees(start+sweep))
+		w.number(51, degrees(start))
+	}
+	return nil
+}
+
+// chainStep is a line or arc walked from one of its endpoints to the other
+type chainStep struct {
+	segment  sketch.SketchElement
+	from, to int
+}
+
+// chain is a run of connected segments
+type chain struct {
+	steps  []chainStep
+	closed bool
+}
+
+// chainSegments joins the lines and arcs that meet at points shared by exactly two of
+// them. Chains start at the open ends, what is left over are closed loops
+func chainSegments(segments []sketch.SketchElement) []chain {
+	endpoints := func(segment sketch.SketchElement) (int, int) {
+		switch e := segment.(type) {
+		case *sketch.SketchLine:
+			return e.StartId, e.EndId
+		case *sketch.SketchArc:
+			return e.StartId, e.EndId
+		}
+		return 0, 0
+	}
+
+	at := map[int][]int{}
+	for i, segment := range segments {
+		from, to := endpoints(segment)
+		at[from] = append(at[from], i)
+		at[to] = append(at[to], i)
+	}
+
+	visited := make([]bool, len(segments))
+	walk := func(i, from int) chain {
+		c := chain{}
+		for {
+			visited[i] = true
+			start, end := endpoints(segments[i])
+			to := end
+			if from == end {
+				to = start
+			}
+			c.steps = append(c.steps, chainStep{segment: segments[i], from: from, to: to})
+
+			if len(at[to]) != 2 {
+				return c
+			}
+			next := at[to][0]
+			if next == i {
+				next = at[to][1]
+			}
+			if visited[next] {
+				c.closed = to == c.steps[0].from
+				return c
+			}
+			i, from = next, to
+		}
+	}
+
+	chains := make([]chain, 0)
+	// open chains first, so they are walked from one end
+	for i, segment := range segments {
+		start, end := endpoints(segment)
+		if visited[i] {
+			continue
+		}
+		if len(at[start]) != 2 {
+			chains = append(chains, walk(i, start))
+		} else if len(at[end]) != 2 {
+			chains = append(chains, walk(i, end))
+		}
+	}
+	for i, segment := range segments {
+		if !visited[i] {
+			start, _ := endpoints(segment)
+			chains = append(chains, walk(i, start))
+		}
+	}
+	return chains
+}
+
+// polyline writes a chain as LWPOLYLINE. Arcs become the bulge of the vertex they start at,
+// which is the tangent of a quarter of the included angle, positive counter clockwise
+func (w *writer) polyline(s *sketch.Sketch, c chain) error {
+	type vertex struct {
+		position geom.Vec2
+		bulge    float64
+	}
+	vertices := make([]vertex, 0, len(c.steps)+1)
+	for _, step := range c.steps {
+		from, err := sketch.GetElementByID[*sketch.SketchPoint](s, step.from)
+		if err != nil {
+			return err
+		}
+		v := vertex{position: from.Position}
+		if arc, ok := step.segment.(*sketch.SketchArc); ok {
+			_, sweep, err := arc.GetAngles(s)
+			if err != nil {
+				return err
+			}
+			// the sketch arc turns clockwise once flipped
+			v.bulge = -math.Tan(sweep / 4)
+			if step.from == arc.EndId {
+				v.bulge = -v.bulge
+			}
+		}
+		vertices = append(vertices, v)
+	}
+	if !c.closed {
+		last, err := sketch.GetElementByID[*sketch.SketchPoint](s, c.steps[len(c.steps)-1].to)
+		if err != nil {
+			return err
+		}
+		vertices = append(vertices, vertex{position: last.Position})
+	}
+
+	w.entity("LWPOLYLINE", "AcDbPolyline")
+	w.pair(90, strconv.Itoa(len(vertices)))
+	flags := 0
+	if c.closed {
+		flags = 1
+	}
+	w.pair(70, strconv.Itoa(flags))
+	for _, v := range vertices {
+		w.point(10, v.position)
+		if v.bulge != 0 {
+			w.number(42, v.bulge)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package dxf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+
+	"unholy-cad/geom"
+	"unholy-cad/sketch"
+)
+
+// describe lists the geometry of a sketch by position, so sketches can be compared
+// whatever their ids and element order. Lines are the same either way around, arcs run from
+// their start to their end
+func describe(t *testing.T, s *sketch.Sketch) []string {
+	t.Helper()
+	format := func(p geom.Vec2) string {
+		// rounded to hide the last bits of the flips and angle conversions, +0 drops the sign of -0
+		return fmt.Sprintf("(%g, %g)", math.Round(p.X*1e6)/1e6+0, math.Round(p.Y*1e6)/1e6+0)
+	}
+	position := func(id int) string {
+		p, err := sketch.GetElementByID[*sketch.SketchPoint](s, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return format(p.Position)
+	}
+
+	used := map[int]bool{}
+	geometry := make([]string, 0)
+	for _, element := range s.Elements {
+		if sketch.IsBuiltin(element.GetId()) {
+			continue
+		}
+		switch e := element.(type) {
+		case *sketch.SketchLine:
+			ends := []string{position(e.StartId), position(e.EndId)}
+			sort.Strings(ends)
+			geometry = append(geometry, fmt.Sprintf("line %s %s", ends[0], ends[1]))
+		case *sketch.SketchCircle:
+			geometry = append(geometry, fmt.Sprintf("circle %s %g", position(e.CenterId), math.Round(e.Radius*1e6)/1e6))
+		case *sketch.SketchArc:
+			geometry = append(geometry, fmt.Sprintf("arc %s %s %s", position(e.CenterId), position(e.StartId), position(e.EndId)))
+		default:
+			continue
+		}
+		for _, id := range element.GetReferences() {
+			used[id] = true
+		}
+	}
+	for _, element := range s.Elements {
+		if p, ok := element.(*sketch.SketchPoint); ok && !sketch.IsBuiltin(p.Id) && !used[p.Id] {
+			geometry = append(geometry, "point "+format(p.Position))
+		}
+	}
+	sort.Strings(geometry)
+	return geometry
+}
+
+// countPoints counts the points of a sketch without the origin
+func countPoints(s *sketch.Sketch) int {
+	count := 0
+	for _, element := range s.Elements {
+		if _, ok := element.(*sketch.SketchPoint); ok && !sketch.IsBuiltin(element.GetId()) {
+			count++
+		}
+	}
+	return count
+}
+
+// profileSketch has a closed profile with a rounded corner, an open chain ending in a half
+// circle, a circle and a lone point
+func profileSketch() *sketch.Sketch {
+	s := sketch.NewSketch()
+	point := func(x, y float64) *sketch.SketchPoint {
+		return s.AddPoint(geom.Vec2{X: x, Y: y})
+	}
+
+	a, b, c, d := point(0, 0), point(40, 0), point(40, 30), point(10, 30)
+	corner, f := point(10, 20), point(0, 20)
+	s.AddLine(a.Id, b.Id)
+	s.AddLine(b.Id, c.Id)
+	s.AddLine(c.Id, d.Id)
+	s.AddArc(corner.Id, d.Id, f.Id)
+	s.AddLine(f.Id, a.Id)
+
+	g, h, i, center := point(0, 50), point(20, 50), point(40, 50), point(30, 50)
+	s.AddLine(g.Id, h.Id)
+	s.AddArc(center.Id, h.Id, i.Id)
+
+	s.AddCircle(point(60, 15).Id, 5)
+	point(80, -10)
+	return s
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+	}{
+		{"R12", Options{Version: R12}},
+		{"R2000", Options{Version: R2000}},
+		{"polylines", Options{Version: R2000, Polylines: true}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := profileSketch()
+			data, err := Export(s, test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			imported, err := Import(bytes.NewReader(data), DefaultTolerance)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := describe(t, imported), describe(t, s); !reflect.DeepEqual(got, want) {
+				t.Errorf("got\n%v\nwant\n%v", got, want)
+			}
+			// connected segments share their endpoints again
+			if got, want := countPoints(imported), countPoints(s); got != want {
+				t.Errorf("got %d points, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestExportPolylinesNeedR2000(t *testing.T) {
+	if _, err := Export(profileSketch(), Options{Version: R12, Polylines: true}); err == nil {
+		t.Error("R12 polylines exported")
+	}
+}
@@ -0,0 +1,333 @@
+package dxf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"unholy-cad/geom"
+	"unholy-cad/sketch"
+)
+
+// DefaultTolerance is the distance within which imported endpoints become one point
+const DefaultTolerance = 1e-6
+
+// group is one group code and value pair
+type group struct {
+	code  int
+	value string
+}
+
+// entity is a DXF entity with its groups in file order
+type entity struct {
+	kind   string
+	groups []group
+}
+
+func (e *entity) number(code int) float64 {
+	for _, g := range e.groups {
+		if g.code == code {
+			v, _ := strconv.ParseFloat(g.value, 64)
+			return v
+		}
+	}
+	return 0
+}
+
+func (e *entity) integer(code int) int {
+	return int(e.number(code))
+}
+
+// mirrored reports whether the entity is drawn in a plane seen from below, which
+// mirrors its x axis
+func (e *entity) mirrored() bool {
+	return e.number(230) < 0
+}
+
+// readGroups reads the group code and value pairs of a file
+func readGroups(r io.Reader) ([]group, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	groups := make([]group, 0)
+	line := 0
+	for scanner.Scan() {
+		line++
+		code, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid group code %q", line, scanner.Text())
+		}
+		if !scanner.Scan() {
+			return nil, fmt.Errorf("line %d: group code %d without a value", line, code)
+		}
+		line++
+		groups = append(groups, group{code: code, value: strings.TrimSpace(scanner.Text())})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// readEntities returns the entities of the ENTITIES section. The vertices of an old style
+// POLYLINE are kept as entities of their own following it
+func readEntities(groups []group) []*entity {
+	entities := make([]*entity, 0)
+	inEntities := false
+	var current *entity
+	for i, g := range groups {
+		if g.code != 0 {
+			if current != nil {
+				current.groups = append(current.groups, g)
+			}
+			continue
+		}
+
+		current = nil
+		switch {
+		case g.value == "SECTION" && i+1 < len(groups) && groups[i+1].code == 2:
+			inEntities = groups[i+1].value == "ENTITIES"
+		case g.value == "ENDSEC":
+			inEntities = false
+		case inEntities:
+			current = &entity{kind: g.value}
+			entities = append(entities, current)
+		}
+	}
+	return entities
+}
+
+// importer adds the geometry to a new sketch and reuses points within the tolerance
+type importer struct {
+	sketch    *sketch.Sketch
+	tolerance float64
+	nextId    int
+	// points by the grid cell of size tolerance they fall into
+	cells map[[2]int64][]*sketch.SketchPoint
+}
+
+// maxCell bounds the cell coordinates, so they stay exact in a float64 and convert to
+// int64 without overflowing
+const maxCell = 1 << 52
+
+// cell fails for positions that are not finite or so far from the origin, measured in
+// tolerances, that their cell cannot be represented
+func (im *importer) cell(p geom.Vec2) ([2]int64, error) {
+	x, y := math.Floor(p.X/im.tolerance), math.Floor(p.Y/im.tolerance)
+	if !(math.Abs(x) < maxCell && math.Abs(y) < maxCell) {
+		return [2]int64{}, fmt.Errorf("point (%g, %g) is too far from the origin for the tolerance %g", p.X, -p.Y, im.tolerance)
+	}
+	return [2]int64{int64(x), int64(y)}, nil
+}
+
+// point returns the id of the point at a DXF position, adding it when there is none
+// within the tolerance yet
+func (im *importer) point(x, y float64) (int, error) {
+	position := geom.Vec2{X: x, Y: -y}
+	if position.Y == 0 {
+		// no negative zero
+		position.Y = 0
+	}
+	cell, err := im.cell(position)
+	if err != nil {
+		return 0, err
+	}
+	for dx := int64(-1); dx <= 1; dx++ {
+		for dy := int64(-1); dy <= 1; dy++ {
+			for _, p := range im.cells[[2]int64{cell[0] + dx, cell[1] + dy}] {
+				if p.Position.DistanceTo(position) <= im.tolerance {
+					return p.Id, nil
+				}
+			}
+		}
+	}
+
+	p := &sketch.SketchPoint{Id: im.id(), Position: position}
+	im.cells[cell] = append(im.cells[cell], p)
+	im.sketch.Elements = append(im.sketch.Elements, p)
+	return p.Id, nil
+}
+
+func (im *importer) id() int {
+	id := im.nextId
+	im.nextId++
+	return id
+}
+
+func (im *importer) line(x1, y1, x2, y2 float64) error {
+	startId, err := im.point(x1, y1)
+	if err != nil {
+		return err
+	}
+	endId, err := im.point(x2, y2)
+	if err != nil {
+		return err
+	}
+	if startId == endId {
+		return nil
+	}
+	im.sketch.Elements = append(im.sketch.Elements, &sketch.SketchLine{Id: im.id(), StartId: startId, EndId: endId})
+	return nil
+}
+
+func (im *importer) circle(x, y, radius float64) error {
+	if radius <= im.tolerance {
+		return nil
+	}
+	centerId, err := im.point(x, y)
+	if err != nil {
+		return err
+	}
+	im.sketch.Elements = append(im.sketch.Elements, &sketch.SketchCircle{Id: im.id(), CenterId: centerId, Radius: radius})
+	return nil
+}
+
+// arc adds the arc running counter clockwise in DXF from the start angle to the end angle,
+// in degrees. Flipped, the sketch arc runs from the end to the start
+func (im *importer) arc(x, y, radius, startAngle, endAngle float64) error {
+	if radius <= im.tolerance {
+		return nil
+	}
+	if math.Mod(endAngle-startAngle+360, 360) == 0 {
+		return im.circle(x, y, radius)
+	}
+	start := startAngle * math.Pi / 180
+	end := endAngle * math.Pi / 180
+	centerId, err := im.point(x, y)
+	if err != nil {
+		return err
+	}
+	startId, err := im.point(x+radius*math.Cos(end), y+radius*math.Sin(end))
+	if err != nil {
+		return err
+	}
+	endId, err := im.point(x+radius*math.Cos(start), y+radius*math.Sin(start))
+	if err != nil {
+		return err
+	}
+	if startId == endId {
+		return nil
+	}
+	im.sketch.Elements = append(im.sketch.Elements, &sketch.SketchArc{Id: im.id(), CenterId: centerId, StartId: startId, EndId: endId})
+	return nil
+}
+
+// bulge adds the segment between two polyline vertices, an arc when the bulge is not zero.
+// An arc that strays less than the tolerance from its chord is a line, its center would be
+// far out otherwise
+func (im *importer) bulge(from, to geom.Vec2, bulge float64) error {
+	if math.Abs(bulge)*from.DistanceTo(to)/2 <= im.tolerance {
+		return im.line(from.X, from.Y, to.X, to.Y)
+	}
+	// the center is on the perpendicular bisector, at the distance that makes the
+	// included angle four times the arc tangent of the bulge
+	chord := to.Sub(from)
+	middle := from.Lerp(to, 0.5)
+	offset := chord.Tangent().Mul((1 - bulge*bulge) / (4 * bulge))
+	center := middle.Add(offset)
+	radius := center.DistanceTo(from)
+
+	startAngle := math.Atan2(from.Y-center.Y, from.X-center.X) * 180 / math.Pi
+	endAngle := math.Atan2(to.Y-center.Y, to.X-center.X) * 180 / math.Pi
+	if bulge < 0 {
+		// clockwise from the first vertex is counter clockwise from the second
+		startAngle, endAngle = endAngle, startAngle
+	}
+	return im.arc(center.X, center.Y, radius, startAngle, endAngle)
+}
+
+// polyline adds the segments between the vertices, back to the first one when closed
+func (im *importer) polyline(vertices []geom.Vec2, bulges []float64, closed bool) error {
+	for i := 0; i+1 < len(vertices); i++ {
+		if err := im.bulge(vertices[i], vertices[i+1], bulges[i]); err != nil {
+			return err
+		}
+	}
+	if closed && len(vertices) > 2 {
+		last := len(vertices) - 1
+		return im.bulge(vertices[last], vertices[0], bulges[last])
+	}
+	return nil
+}
+
+// Import reads the POINT, LINE, CIRCLE, ARC, LWPOLYLINE and POLYLINE entities of a DXF file
+// into a new sketch. Other entities are skipped. Endpoints closer than the tolerance are
+// merged into one point, so connected geometry shares its points. Coordinates further from
+// the origin than about 4e15 tolerances cannot be merged and fail the import
+func Import(r io.Reader, tolerance float64) (*sketch.Sketch, error) {
+	if tolerance <= 0 {
+		return nil, fmt.Errorf("tolerance has to be positive, got %g", tolerance)
+	}
+	groups, err := readGroups(r)
+	if err != nil {
+		return nil, err
+	}
+
+	im := &importer{
+		sketch:    sketch.NewSketch(),
+		tolerance: tolerance,
+		cells:     map[[2]int64][]*sketch.SketchPoint{},
+	}
+
+	entities := readEntities(groups)
+	for i := 0; i < len(entities); i++ {
+		e := entities[i]
+		// entities in a mirrored plane have their x axis pointing the other way, which
+		// also turns their arcs around
+		sign := 1.0
+		if e.mirrored() {
+			sign = -1
+		}
+
+		switch e.kind {
+		case "POINT":
+			_, err = im.point(e.number(10), e.number(20))
+		case "LINE":
+			err = im.line(e.number(10), e.number(20), e.number(11), e.number(21))
+		case "CIRCLE":
+			err = im.circle(sign*e.number(10), e.number(20), e.number(40))
+		case "ARC":
+			start, end := e.number(50), e.number(51)
+			if sign < 0 {
+				start, end = 180-end, 180-start
+			}
+			err = im.arc(sign*e.number(10), e.number(20), e.number(40), start, end)
+		case "LWPOLYLINE":
+			vertices := make([]geom.Vec2, 0)
+			bulges := make([]float64, 0)
+			for _, g := range e.groups {
+				v, _ := strconv.ParseFloat(g.value, 64)
+				switch g.code {
+				case 10:
+					vertices = append(vertices, geom.Vec2{X: sign * v})
+					bulges = append(bulges, 0)
+				case 20:
+					if len(vertices) > 0 {
+						vertices[len(vertices)-1].Y = v
+					}
+				case 42:
+					if len(bulges) > 0 {
+						bulges[len(bulges)-1] = sign * v
+					}
+				}
+			}
+			err = im.polyline(vertices, bulges, e.integer(70)&1 != 0)
+		case "POLYLINE":
+			// the vertices follow as entities up to SEQEND
+			vertices := make([]geom.Vec2, 0)
+			bulges := make([]float64, 0)
+			for i+1 < len(entities) && entities[i+1].kind == "VERTEX" {
+				i++
+				vertices = append(vertices, geom.Vec2{X: sign * entities[i].number(10), Y: entities[i].number(20)})
+				bulges = append(bulges, sign*entities[i].number(42))
+			}
+			err = im.polyline(vertices, bulges, e.integer(70)&1 != 0)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.kind, err)
+		}
+	}
+	return im.sketch, nil
+}
@@ -0,0 +1,170 @@
+package dxf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"unholy-cad/sketch"
+)
+
+// entities wraps group code and value pairs into a DXF file with only an ENTITIES section
+func entities(pairs ...string) string {
+	lines := append([]string{"0", "SECTION", "2", "ENTITIES"}, pairs...)
+	lines = append(lines, "0", "ENDSEC", "0", "EOF")
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func importString(t *testing.T, data string, tolerance float64) *sketch.Sketch {
+	t.Helper()
+	s, err := Import(strings.NewReader(data), tolerance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			// y points up in DXF and down in the sketch
+			name: "y flip",
+			data: entities(
+				"0", "POINT", "10", "5", "20", "7",
+				"0", "LINE", "10", "0", "20", "0", "11", "10", "21", "20",
+			),
+			want: []string{"line (0, 0) (10, -20)", "point (5, -7)"},
+		},
+		{
+			name: "arc",
+			data: entities("0", "ARC", "10", "10", "20", "0", "40", "5", "50", "90", "51", "180"),
+			want: []string{"arc (10, 0) (5, 0) (10, -5)"},
+		},
+		{
+			// seen from below the x axis of the plane points the other way, an arc drawn
+			// counter clockwise in it is clockwise on the sheet
+			name: "mirrored",
+			data: entities(
+				"0", "ARC", "10", "10", "20", "0", "40", "5", "50", "0", "51", "90", "230", "-1",
+				"0", "CIRCLE", "10", "30", "20", "5", "40", "2", "230", "-1.0",
+			),
+			want: []string{"arc (-10, 0) (-15, 0) (-10, -5)", "circle (-30, -5) 2"},
+		},
+		{
+			name: "bulge",
+			data: entities("0", "LWPOLYLINE", "90", "2", "70", "0", "10", "0", "20", "0", "42", "1", "10", "10", "20", "0"),
+			want: []string{"arc (5, 0) (10, 0) (0, 0)"},
+		},
+		{
+			name: "negative bulge",
+			data: entities("0", "LWPOLYLINE", "90", "2", "70", "0", "10", "0", "20", "0", "42", "-1", "10", "10", "20", "0"),
+			want: []string{"arc (5, 0) (0, 0) (10, 0)"},
+		},
+		{
+			name: "mirrored bulge",
+			data: entities("0", "LWPOLYLINE", "90", "2", "70", "0", "10", "0", "20", "0", "42", "1", "10", "10", "20", "0", "230", "-1"),
+			want: []string{"arc (-5, 0) (0, 0) (-10, 0)"},
+		},
+		{
+			// so small the arc is its chord, its center would be far out otherwise
+			name: "flat bulge",
+			data: entities("0", "LWPOLYLINE", "90", "2", "70", "0", "10", "0", "20", "0", "42", "1e-15", "10", "10", "20", "0"),
+			want: []string{"line (0, 0) (10, 0)"},
+		},
+		{
+			name: "closed polyline with vertices",
+			data: entities(
+				"0", "POLYLINE", "66", "1", "70", "1",
+				"0", "VERTEX", "10", "0", "20", "0",
+				"0", "VERTEX", "10", "10", "20", "0", "42", "1",
+				"0", "VERTEX", "10", "10", "20", "10",
+				"0", "SEQEND",
+			),
+			// the bulge turns counter clockwise on the sheet, out of the triangle
+			want: []string{"arc (10, -5) (10, -10) (10, 0)", "line (0, 0) (10, -10)", "line (0, 0) (10, 0)"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := importString(t, test.data, DefaultTolerance)
+			if got := describe(t, s); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestImportMergesEndpoints(t *testing.T) {
+	tests := []struct {
+		name      string
+		x, y      string
+		tolerance float64
+		shared    bool
+	}{
+		{"same", "10", "0", 1e-6, true},
+		{"within", "10.0000004", "0.0000003", 1e-6, true},
+		// the cells are a tolerance wide, close points in neighbouring cells merge too
+		{"across a cell", "9.9999995", "-0.0000005", 1e-6, true},
+		{"outside", "10.000002", "0", 1e-6, false},
+		{"coarse", "10.02", "0.01", 0.1, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := importString(t, entities(
+				"0", "LINE", "10", "0", "20", "0", "11", "10", "21", "0",
+				"0", "LINE", "10", test.x, "20", test.y, "11", "10", "21", "10",
+			), test.tolerance)
+
+			lines := make([]*sketch.SketchLine, 0)
+			for _, element := range s.Elements {
+				if line, ok := element.(*sketch.SketchLine); ok && !sketch.IsBuiltin(line.Id) {
+					lines = append(lines, line)
+				}
+			}
+			if len(lines) != 2 {
+				t.Fatalf("got %d lines, want 2", len(lines))
+			}
+			if shared := lines[0].EndId == lines[1].StartId; shared != test.shared {
+				t.Errorf("end %d and start %d shared %v, want %v", lines[0].EndId, lines[1].StartId, shared, test.shared)
+			}
+			want := 4
+			if test.shared {
+				want = 3
+			}
+			if got := countPoints(s); got != want {
+				t.Errorf("got %d points, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestImportFarPoints(t *testing.T) {
+	tests := []struct {
+		name      string
+		x         string
+		tolerance float64
+	}{
+		{"far", "1e20", DefaultTolerance},
+		{"tiny tolerance", "1000", 1e-20},
+		{"infinite", "inf", DefaultTolerance},
+		{"not a number", "nan", DefaultTolerance},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := entities("0", "LINE", "10", "0", "20", "0", "11", test.x, "21", "0")
+			if _, err := Import(strings.NewReader(data), test.tolerance); err == nil {
+				t.Error("imported a point whose cell overflows")
+			}
+		})
+	}
+
+	// a large drawing with a tolerance to match still imports
+	data := entities("0", "LINE", "10", "0", "20", "0", "11", "1e12", "21", "0")
+	if got := describe(t, importString(t, data, 1e-3)); len(got) != 1 {
+		t.Errorf("got %v, want one line", got)
+	}
+}
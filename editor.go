@@ -0,0 +1,217 @@
+//go:build !headless
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"log"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"unholy-cad/geom"
+	"unholy-cad/sketch"
+	"unholy-cad/view"
+)
+
+const (
+	// two clicks closer than this in time and distance are a double click
+	doubleClickTime     = 400 * time.Millisecond
+	doubleClickDistance = 4.0
+)
+
+type click struct {
+	time     time.Time
+	position geom.Vec2
+}
+
+// dimensionEditor edits the value of one dimension in place of its label
+type dimensionEditor struct {
+	textField
+	dimensionId int
+	// top left corner of the label on screen
+	position geom.Vec2
+	// constraints that contradict the last rejected value
+	conflicts []int
+}
+
+// dimensionLabelHit returns the dimension whose label is under a screen position, the
+// label drawn last wins because it is on top
+func (g *Game) dimensionLabelHit(screenPos geom.Vec2) (view.Label, bool) {
+	for i := len(g.dimensionLabels) - 1; i >= 0; i-- {
+		label := g.dimensionLabels[i]
+		if screenPos.X >= label.Min.X && screenPos.X <= label.Max.X && screenPos.Y >= label.Min.Y && screenPos.Y <= label.Max.Y {
+			return label, true
+		}
+	}
+	return view.Label{}, false
+}
+
+// updateDimensionLabels opens the dimension editor when a label is double clicked.
+// Returns true when the click was used
+func (g *Game) updateDimensionLabels(mouse geom.Vec2) bool {
+	if !inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		return false
+	}
+	now := time.Now()
+	previous := g.lastClick
+	g.lastClick = click{time: now, position: mouse}
+	if now.Sub(previous.time) > doubleClickTime || mouse.DistanceTo(previous.position) > doubleClickDistance {
+		return false
+	}
+
+	label, ok := g.dimensionLabelHit(mouse)
+	if !ok {
+		return false
+	}
+	d, err := sketch.GetElementByID[sketch.SketchDimension](&g.sketch, label.Id)
+	if err != nil {
+		return false
+	}
+	text := d.GetExpression()
+	if text == "" {
+		text = fmt.Sprint(roundDimension(d.GetValue()))
+	}
+	g.dimensionEditor = dimensionEditor{
+		textField:   textField{active: true, text: text},
+		dimensionId: d.GetId(),
+		position:    label.Min,
+	}
+	g.drag.active = false
+	g.lastClick = click{}
+	return true
+}
+
+// updateDimensionEditor handles typing while the dimension editor is open. Tab switches
+// the dimension between driving the geometry and being a reference
+func (g *Game) updateDimensionEditor() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		g.toggleReference()
+		return
+	}
+	if !g.dimensionEditor.update() {
+		if !g.dimensionEditor.active {
+			g.dimensionEditor = dimensionEditor{}
+		}
+		return
+	}
+
+	editor := &g.dimensionEditor
+	d, err := sketch.GetElementByID[sketch.SketchDimension](&g.sketch, editor.dimensionId)
+	if err != nil {
+		g.dimensionEditor = dimensionEditor{}
+		return
+	}
+	err = g.editAndSolve("Set "+d.GetName(), func() error {
+		return g.sketch.EditDimension(editor.dimensionId, editor.text)
+	})
+	if err != nil {
+		log.Printf("Could not set %s to %q: %v", d.GetName(), editor.text, err)
+		editor.err = err
+		var conflict *conflictError
+		if errors.As(err, &conflict) {
+			editor.conflicts = conflict.ids
+		}
+		return
+	}
+	g.dimensionEditor = dimensionEditor{}
+}
+
+// toggleReference turns the dimension in the editor into a reference or back into a
+// driving dimension, keeping the editor open when that fails
+func (g *Game) toggleReference() {
+	editor := &g.dimensionEditor
+	d, err := sketch.GetElementByID[sketch.SketchDimension](&g.sketch, editor.dimensionId)
+	if err != nil {
+		g.dimensionEditor = dimensionEditor{}
+		return
+	}
+	label := "Reference " + d.GetName()
+	if d.IsReference() {
+		label = "Drive " + d.GetName()
+	}
+	reference := !d.IsReference()
+	err = g.editAndSolve(label, func() error {
+		return g.sketch.SetDimensionReference(editor.dimensionId, reference)
+	})
+	if err != nil {
+		log.Printf("Could not change %s: %v", d.GetName(), err)
+		editor.err = err
+		var conflict *conflictError
+		if errors.As(err, &conflict) {
+			editor.conflicts = conflict.ids
+		}
+		return
+	}
+	g.dimensionEditor = dimensionEditor{}
+}
+
+// conflictError is returned when an edit leaves the sketch without a solution
+type conflictError struct {
+	// constraints that contradict the others
+	ids []int
+}
+
+func (e *conflictError) Error() string {
+	if len(e.ids) == 0 {
+		return "no solution found"
+	}
+	return "no solution, conflicts with " + joinIds(e.ids)
+}
+
+// editAndSolve applies a change as one undo step and solves the sketch. When the change
+// fails or the sketch cannot be solved afterwards, the sketch is reverted
+func (g *Game) editAndSolve(label string, change func() error) error {
+	var err error
+	g.edit(label, func() {
+		before := g.sketch.Clone()
+		if err = change(); err != nil {
+			g.sketch = *before
+			return
+		}
+		result, solveErr := g.solve()
+		if solveErr != nil {
+			err = solveErr
+		} else if !result.Satisfied {
+			conflict := &conflictError{}
+			if analysis, analysisErr := g.sketch.AnalyzeDegreesOfFreedom(); analysisErr == nil {
+				conflict.ids = analysis.Conflicting
+			}
+			err = conflict
+		}
+		if err != nil {
+			g.sketch = *before
+		}
+	})
+	return err
+}
+
+func (g *Game) drawDimensionEditor(screen *ebiten.Image) {
+	editor := g.dimensionEditor
+	if !editor.active {
+		return
+	}
+	text := editor.text + "_"
+	width := MeasureText(text) + 8
+	if width < 60 {
+		width = 60
+	}
+	topLeft := editor.position.Sub(geom.Vec2{X: 4, Y: 2})
+	vector.DrawFilledRect(screen, float32(topLeft.X), float32(topLeft.Y), float32(width), view.LabelHeight+4, color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}, false)
+	vector.StrokeRect(screen, float32(topLeft.X), float32(topLeft.Y), float32(width), view.LabelHeight+4, 1, color.RGBA{0x33, 0x99, 0xff, 0xFF}, false)
+	DrawText(screen, text, editor.position, color.RGBA{0x11, 0x11, 0x11, 0xFF})
+	below := editor.position.Add(geom.Vec2{Y: view.LabelHeight + 4})
+	if editor.err != nil {
+		DrawText(screen, editor.err.Error(), below, view.StateColor(sketch.OverConstrained))
+	} else if d, err := sketch.GetElementByID[sketch.SketchDimension](&g.sketch, editor.dimensionId); err == nil {
+		hint := "tab: reference"
+		if d.IsReference() {
+			hint = "tab: driving"
+		}
+		DrawText(screen, hint, below, color.RGBA{0x88, 0x88, 0x88, 0xFF})
+	}
+}
@@ -0,0 +1,282 @@
+//go:build !headless
+
+package main
+
+import (
+	"flag"
+	"image/color"
+	"log"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"unholy-cad/geom"
+	"unholy-cad/sketch"
+	"unholy-cad/view"
+)
+
+type Game struct {
+	camera       view.Camera
+	lastMousePos geom.Vec2
+	isPanning    bool
+
+	// element under the cursor and the points being dragged
+	hoverId int
+	drag    pointDrag
+	// selected element ids in the order they were clicked
+	selection []int
+
+	tool    Tool
+	pending pendingVertex
+	// start point of an arc once its center has been placed
+	pendingArcStart pendingVertex
+
+	history History
+	command textField
+	// inline editor of a dimension value, opened by double clicking its label
+	dimensionEditor dimensionEditor
+	// screen rectangles of the dimension labels drawn in the last frame
+	dimensionLabels []view.Label
+	lastClick       click
+
+	sketch   sketch.Sketch
+	filePath string
+	dof      sketch.DofAnalysis
+	topology sketch.Topology
+	// dof and topology are up to date with the sketch
+	analyzed bool
+}
+
+func (g *Game) Update() error {
+	if g.command.active {
+		g.updateCommandLine()
+		g.updateAnalysis()
+		return nil
+	}
+	if g.dimensionEditor.active {
+		g.updateDimensionEditor()
+		g.updateAnalysis()
+		return nil
+	}
+
+	if ebiten.IsKeyPressed(ebiten.KeyControl) {
+		if inpututil.IsKeyJustPressed(ebiten.KeyS) {
+			if err := g.sketch.Save(g.filePath); err != nil {
+				log.Printf("Could not save %s: %v", g.filePath, err)
+			} else {
+				log.Printf("Saved %s", g.filePath)
+			}
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyO) {
+			if loaded, err := sketch.Load(g.filePath); err != nil {
+				log.Printf("Could not open %s: %v", g.filePath, err)
+			} else {
+				g.sketch = *loaded
+				g.history.clear()
+				g.invalidateAnalysis()
+				g.selection = nil
+				log.Printf("Opened %s", g.filePath)
+			}
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyZ) {
+			g.drag.active = false
+			g.selection = nil
+			if ebiten.IsKeyPressed(ebiten.KeyShift) {
+				g.history.redo(&g.sketch)
+			} else {
+				g.history.undo(&g.sketch)
+			}
+			g.invalidateAnalysis()
+		}
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		g.edit("Solve", func() {
+			g.solve()
+		})
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyDelete) || inpututil.IsKeyJustPressed(ebiten.KeyBackspace) {
+		ids := g.selection
+		if len(ids) == 0 && g.hoverId != noElement {
+			ids = []int{g.hoverId}
+		}
+		if len(ids) > 0 {
+			g.edit("Delete", func() {
+				for _, id := range ids {
+					g.sketch.DeleteElement(id)
+				}
+			})
+		}
+		g.hoverId = noElement
+		g.selection = nil
+	}
+	if !ebiten.IsKeyPressed(ebiten.KeyControl) {
+		g.updateConstraintHotkeys()
+	}
+
+	// randomly move the position of the point on x key press
+	if inpututil.IsKeyJustPressed(ebiten.KeyX) {
+		g.history.begin("Jitter", &g.sketch)
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyX) {
+		for _, element := range g.sketch.Elements {
+			if point, ok := element.(*sketch.SketchPoint); ok && !sketch.IsBuiltin(point.Id) {
+				point.Position.X += rand.Float64()*2 - 1
+				point.Position.Y += rand.Float64()*2 - 1
+			}
+		}
+		g.invalidateAnalysis()
+	}
+	if inpututil.IsKeyJustReleased(ebiten.KeyX) {
+		g.history.end(&g.sketch)
+	}
+
+	mouseX, mouseY := ebiten.CursorPosition()
+	mouseVec := geom.Vec2{X: float64(mouseX), Y: float64(mouseY)}
+
+	if !g.updateParameterPanel(mouseVec) && !g.updateDimensionLabels(mouseVec) {
+		g.updateTools(mouseVec)
+	}
+
+	// Panning
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonMiddle) || ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight) {
+		if g.isPanning {
+			delta := geom.Vec2{
+				X: mouseVec.X - g.lastMousePos.X,
+				Y: mouseVec.Y - g.lastMousePos.Y,
+			}
+			g.camera.Position.X -= delta.X / g.camera.Scale
+			g.camera.Position.Y -= delta.Y / g.camera.Scale
+		}
+		g.isPanning = true
+		g.lastMousePos = mouseVec
+	} else {
+		g.isPanning = false
+	}
+
+	// Zooming
+	_, dy := ebiten.Wheel()
+	if dy != 0 {
+		g.zoom(mouseVec, dy)
+	}
+
+	g.updateAnalysis()
+	return nil
+}
+
+// updateAnalysis recomputes the degrees of freedom and the topology when the sketch
+// changed since the last update
+func (g *Game) updateAnalysis() {
+	if g.analyzed {
+		return
+	}
+	g.analyzed = true
+
+	dof, err := g.sketch.AnalyzeDegreesOfFreedom()
+	if err != nil {
+		log.Printf("Degrees of freedom analysis failed: %v", err)
+	} else {
+		g.dof = dof
+	}
+	topology, err := g.sketch.AnalyzeTopology()
+	if err != nil {
+		log.Printf("Topology analysis failed: %v", err)
+	} else {
+		g.topology = topology
+	}
+}
+
+// invalidateAnalysis makes the next update analyze the sketch again, it is called
+// whenever the sketch changes
+func (g *Game) invalidateAnalysis() {
+	g.analyzed = false
+}
+
+// solve runs the solver on the sketch and logs how it went
+func (g *Game) solve() (sketch.SolveResult, error) {
+	result, err := g.sketch.AttemptApplyConstraints()
+	for id, invalidErr := range result.Invalid {
+		log.Printf("Constraint %d is invalid: %v", id, invalidErr)
+	}
+	if err != nil {
+		log.Printf("Solve failed: %v", err)
+		return result, err
+	}
+	log.Printf("Solve: %v", result)
+	return result, nil
+}
+
+func (g *Game) zoom(mousePos geom.Vec2, scrollAmount float64) {
+	previousScale := g.camera.Scale
+	g.camera.Scale *= 1 + scrollAmount*0.1
+
+	if g.camera.Scale < 0.1 {
+		g.camera.Scale = 0.1
+	}
+
+	// Adjust the camera position to zoom around the cursor
+	mouseWorldX := (mousePos.X / previousScale) + g.camera.Position.X
+	mouseWorldY := (mousePos.Y / previousScale) + g.camera.Position.Y
+	newMouseWorldX := (mousePos.X / g.camera.Scale) + g.camera.Position.X
+	newMouseWorldY := (mousePos.Y / g.camera.Scale) + g.camera.Position.Y
+
+	g.camera.Position.X += mouseWorldX - newMouseWorldX
+	g.camera.Position.Y += mouseWorldY - newMouseWorldY
+}
+
+func (g *Game) Draw(screen *ebiten.Image) {
+	screen.Fill(color.RGBA{0xFF, 0xFF, 0xFF, 0xFF})
+
+	r := g.renderer()
+	drawErrors := r.Draw(screenCanvas{screen})
+	g.dimensionLabels = r.Labels
+
+	g.drawToolPreview(screen)
+	g.drawDofStatus(screen)
+	g.drawToolbar(screen)
+	g.drawParameterPanel(screen)
+	g.drawPropertiesPanel(screen)
+	g.drawErrors(screen, drawErrors)
+	g.drawCommandLine(screen)
+	g.drawDimensionEditor(screen)
+}
+
+func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return screenWidth, screenHeight
+}
+
+// runEditor opens the editor window and blocks until it is closed
+func runEditor() {
+	openPath := flag.String("open", "", "sketch file to open, ctrl+s saves back to it")
+	flag.Parse()
+
+	initFonts()
+	ebiten.SetWindowSize(screenWidth, screenHeight)
+	ebiten.SetWindowTitle("Unholy CAD")
+
+	s := sketch.NewSketch()
+	filePath := defaultSketchPath
+	if *openPath != "" {
+		loaded, err := sketch.Load(*openPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s = loaded
+		filePath = *openPath
+	}
+
+	if err := ebiten.RunGame(&Game{
+		// the origin starts in the middle of the window
+		camera: view.Camera{
+			Position:  geom.Vec2{X: -screenWidth / 2 / 20, Y: -screenHeight / 2 / 20},
+			Scale:     20,
+			PixelSnap: true,
+		},
+		sketch:   *s,
+		filePath: filePath,
+		hoverId:  noElement,
+	}); err != nil {
+		log.Fatal(err)
+	}
+}
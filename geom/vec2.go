@@ -0,0 +1,117 @@
+package geom
+
+import (
+	"math"
+)
+
+type Vec2 struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+func (v Vec2) DistanceTo(other Vec2) float64 {
+	dx := v.X - other.X
+	dy := v.Y - other.Y
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+func (v Vec2) Lerp(other Vec2, t float64) Vec2 {
+	return Vec2{
+		X: v.X + (other.X-v.X)*t,
+		Y: v.Y + (other.Y-v.Y)*t,
+	}
+}
+
+func (v Vec2) Add(other Vec2) Vec2 {
+	return Vec2{
+		X: v.X + other.X,
+		Y: v.Y + other.Y,
+	}
+}
+
+func (v Vec2) Sub(other Vec2) Vec2 {
+	return Vec2{
+		X: v.X - other.X,
+		Y: v.Y - other.Y,
+	}
+}
+
+func (v Vec2) Mul(scalar float64) Vec2 {
+	return Vec2{
+		X: v.X * scalar,
+		Y: v.Y * scalar,
+	}
+}
+
+func (v Vec2) Div(scalar float64) Vec2 {
+	return Vec2{
+		X: v.X / scalar,
+		Y: v.Y / scalar,
+	}
+}
+
+func (v Vec2) Dot(other Vec2) float64 {
+	return v.X*other.X + v.Y*other.Y
+}
+
+// z component of the 3d cross product
+func (v Vec2) Cross(other Vec2) float64 {
+	return v.X*other.Y - v.Y*other.X
+}
+
+func (v Vec2) Magnitude() float64 {
+	return math.Sqrt(v.X*v.X + v.Y*v.Y)
+}
+
+func (v Vec2) Rotate(angle float64) Vec2 {
+	sin := math.Sin(angle)
+	cos := math.Cos(angle)
+	return Vec2{
+		X: v.X*cos - v.Y*sin,
+		Y: v.X*sin + v.Y*cos,
+	}
+}
+
+func (v Vec2) RotateAround(origin Vec2, angle float64) Vec2 {
+	sin := math.Sin(angle)
+	cos := math.Cos(angle)
+
+	// translate point back to origin:
+	v.X -= origin.X
+	v.Y -= origin.Y
+
+	// rotate point
+	xnew := v.X*cos - v.Y*sin
+	ynew := v.X*sin + v.Y*cos
+
+	// translate point back:
+	v.X = xnew + origin.X
+	v.Y = ynew + origin.Y
+	return v
+}
+
+func (v Vec2) Normalize() Vec2 {
+	amag := 1 / v.Magnitude()
+	return Vec2{
+		X: v.X * amag,
+		Y: v.Y * amag,
+	}
+}
+
+func (v Vec2) Tangent() Vec2 {
+	return Vec2{
+		X: -v.Y,
+		Y: v.X,
+	}
+}
+
+func (v Vec2) Clone() Vec2 {
+	return Vec2{
+		X: v.X,
+		Y: v.Y,
+	}
+}
+
+func (v Vec2) Angle() float64 {
+	return math.Atan2(v.Y, -v.X)
+}
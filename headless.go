@@ -0,0 +1,16 @@
+//go:build headless
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// runEditor only prints the commands in builds without the editor, which don't need
+// a display or the graphics libraries
+func runEditor() {
+	fmt.Fprintln(os.Stderr, "usage: unholy-cad <solve|svg|dxf|import|render|measure> [flags] file")
+	fmt.Fprintln(os.Stderr, "this build has no editor, build without the headless tag to open one")
+	os.Exit(exitInvalidInput)
+}
@@ -0,0 +1,117 @@
+//go:build !headless
+
+package main
+
+import (
+	"log"
+
+	"unholy-cad/sketch"
+)
+
+const (
+	// maximum number of undo steps kept, older steps are dropped
+	maxHistory = 100
+	// maximum number of element states kept over all undo steps, so editing a big
+	// sketch doesn't hold on to more and more memory
+	maxHistorySize = 100000
+)
+
+// editCommand is one undo step, holding only the elements the edit changed
+type editCommand struct {
+	label   string
+	changes *sketch.Changes
+}
+
+func (c *editCommand) undo(s *sketch.Sketch) {
+	c.changes.Undo(s)
+}
+
+func (c *editCommand) redo(s *sketch.Sketch) {
+	c.changes.Redo(s)
+}
+
+// openEdit is an edit that started but has not finished yet, such as a drag
+type openEdit struct {
+	label  string
+	before *sketch.Sketch
+}
+
+type History struct {
+	undoStack []*editCommand
+	redoStack []*editCommand
+	// number of element states held by the undo and redo stacks
+	size int
+	open *openEdit
+}
+
+// begin captures the state before an edit that spans several frames
+func (h *History) begin(label string, s *sketch.Sketch) {
+	h.open = &openEdit{label: label, before: s.Clone()}
+}
+
+// end records the edit started with begin as a single step
+func (h *History) end(s *sketch.Sketch) {
+	if h.open == nil {
+		return
+	}
+	open := h.open
+	h.open = nil
+	h.push(&editCommand{label: open.label, changes: sketch.Diff(open.before, s)})
+}
+
+func (h *History) push(command *editCommand) {
+	if command.changes.Empty() {
+		return
+	}
+	for _, dropped := range h.redoStack {
+		h.size -= dropped.changes.Size()
+	}
+	h.redoStack = nil
+	h.undoStack = append(h.undoStack, command)
+	h.size += command.changes.Size()
+	// the newest step is kept even when it is bigger than the limit on its own
+	for len(h.undoStack) > 1 && (len(h.undoStack) > maxHistory || h.size > maxHistorySize) {
+		h.size -= h.undoStack[0].changes.Size()
+		h.undoStack = h.undoStack[1:]
+	}
+}
+
+func (h *History) clear() {
+	h.undoStack = nil
+	h.redoStack = nil
+	h.size = 0
+	h.open = nil
+}
+
+func (h *History) undo(s *sketch.Sketch) {
+	h.end(s)
+	if len(h.undoStack) == 0 {
+		return
+	}
+	command := h.undoStack[len(h.undoStack)-1]
+	h.undoStack = h.undoStack[:len(h.undoStack)-1]
+	command.undo(s)
+	h.redoStack = append(h.redoStack, command)
+	log.Printf("Undo %s", command.label)
+}
+
+func (h *History) redo(s *sketch.Sketch) {
+	h.end(s)
+	if len(h.redoStack) == 0 {
+		return
+	}
+	command := h.redoStack[len(h.redoStack)-1]
+	h.redoStack = h.redoStack[:len(h.redoStack)-1]
+	command.redo(s)
+	h.undoStack = append(h.undoStack, command)
+	log.Printf("Redo %s", command.label)
+}
+
+// edit runs a change to the sketch and records it as one undo step
+func (g *Game) edit(label string, change func()) {
+	g.history.end(&g.sketch)
+	g.history.begin(label, &g.sketch)
+	change()
+	g.history.end(&g.sketch)
+	g.invalidateAnalysis()
+}
@@ -0,0 +1,232 @@
+//go:build !headless
+
+package main
+
+import (
+	"log"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"unholy-cad/geom"
+	"unholy-cad/sketch"
+	"unholy-cad/view"
+)
+
+const (
+	// id used when no element is hovered or selected
+	noElement = view.NoElement
+
+	// hit radius around points and lines in pixels
+	pointHitRadius = 6.0
+	lineHitRadius  = 4.0
+)
+
+type pointDrag struct {
+	active bool
+	// world position of the cursor when the drag started
+	startMouse geom.Vec2
+	// positions of the dragged points when the drag started
+	startPositions map[int]geom.Vec2
+}
+
+// hitTestPoint returns the id of the point under a screen position
+func (g *Game) hitTestPoint(screenPos geom.Vec2) int {
+	closestId := noElement
+	closestDistance := pointHitRadius
+	for _, element := range g.sketch.Elements {
+		if point, ok := element.(*sketch.SketchPoint); ok {
+			if sketch.IsBuiltin(point.Id) && point.Id != sketch.OriginId {
+				// the axis end points are not drawn
+				continue
+			}
+			distance := g.camera.TransformPoint(point.Position).DistanceTo(screenPos)
+			if distance <= closestDistance {
+				closestId = point.Id
+				closestDistance = distance
+			}
+		}
+	}
+	return closestId
+}
+
+// hitTest returns the id of the point, line or curve under a screen position, points win over the rest
+func (g *Game) hitTest(screenPos geom.Vec2) int {
+	if id := g.hitTestPoint(screenPos); id != noElement {
+		return id
+	}
+
+	closestId := noElement
+	closestDistance := lineHitRadius
+	for _, element := range g.sketch.Elements {
+		if line, ok := element.(*sketch.SketchLine); ok {
+			start, end, err := g.renderer().LineExtent(line)
+			if err != nil {
+				continue
+			}
+			distance := distanceToSegment(screenPos, g.camera.TransformPoint(start), g.camera.TransformPoint(end))
+			if distance <= closestDistance {
+				closestId = line.Id
+				closestDistance = distance
+			}
+		}
+		if curve, ok := element.(sketch.SketchCurve); ok {
+			distance, ok := g.distanceToCurve(screenPos, curve)
+			if ok && distance <= closestDistance {
+				closestId = curve.GetId()
+				closestDistance = distance
+			}
+		}
+	}
+	return closestId
+}
+
+// distanceToCurve returns the distance in pixels from a screen position to the outline of
+// a circle or arc. false when the position is outside the angular range of an arc
+func (g *Game) distanceToCurve(screenPos geom.Vec2, curve sketch.SketchCurve) (float64, bool) {
+	center, err := curve.GetCenter(&g.sketch)
+	if err != nil {
+		return 0, false
+	}
+	radius, err := curve.GetRadius(&g.sketch)
+	if err != nil {
+		return 0, false
+	}
+
+	if arc, ok := curve.(*sketch.SketchArc); ok {
+		start, sweep, err := arc.GetAngles(&g.sketch)
+		if err != nil {
+			return 0, false
+		}
+		offset := g.camera.InverseTransformPoint(screenPos).Sub(center.Position)
+		angle := math.Atan2(offset.Y, offset.X) - start
+		if angle < 0 {
+			angle += 2 * math.Pi
+		}
+		if angle > sweep {
+			return 0, false
+		}
+	}
+
+	return math.Abs(g.camera.TransformPoint(center.Position).DistanceTo(screenPos) - radius*g.camera.Scale), true
+}
+
+func distanceToSegment(p, a, b geom.Vec2) float64 {
+	ab := b.Sub(a)
+	lengthSquared := ab.Dot(ab)
+	if lengthSquared == 0 {
+		return p.DistanceTo(a)
+	}
+	t := math.Max(0, math.Min(1, p.Sub(a).Dot(ab)/lengthSquared))
+	return p.DistanceTo(a.Add(ab.Mul(t)))
+}
+
+// dragPointIds returns the points that move when an element is dragged, none for the origin and axes
+func (g *Game) dragPointIds(id int) []int {
+	if sketch.IsBuiltin(id) {
+		return nil
+	}
+	element, err := sketch.GetElementByID[sketch.SketchElement](&g.sketch, id)
+	if err != nil {
+		return nil
+	}
+	switch e := element.(type) {
+	case *sketch.SketchPoint:
+		return []int{e.Id}
+	case *sketch.SketchLine:
+		return []int{e.StartId, e.EndId}
+	case *sketch.SketchCircle:
+		return []int{e.CenterId}
+	case *sketch.SketchArc:
+		return []int{e.CenterId, e.StartId, e.EndId}
+	}
+	return nil
+}
+
+func (g *Game) isSelected(id int) bool {
+	for _, selected := range g.selection {
+		if selected == id {
+			return true
+		}
+	}
+	return false
+}
+
+// updateSelection handles a click on an element, or on empty space when id is noElement.
+// With shift held the element is added to or removed from the selection
+func (g *Game) updateSelection(id int, additive bool) {
+	if !additive {
+		if id == noElement {
+			g.selection = nil
+		} else if !g.isSelected(id) {
+			g.selection = []int{id}
+		}
+		return
+	}
+	if id == noElement {
+		return
+	}
+	for i, selected := range g.selection {
+		if selected == id {
+			g.selection = append(g.selection[:i:i], g.selection[i+1:]...)
+			return
+		}
+	}
+	g.selection = append(g.selection, id)
+}
+
+// updateDrag moves the point or line under the cursor with the left mouse button,
+// re-solving the sketch around it every frame
+func (g *Game) updateDrag(mouse geom.Vec2, clicked bool) {
+	if !g.drag.active {
+		g.hoverId = g.hitTest(mouse)
+	}
+
+	additive := ebiten.IsKeyPressed(ebiten.KeyShift)
+	if clicked {
+		g.updateSelection(g.hoverId, additive)
+	}
+
+	if ids := g.dragPointIds(g.hoverId); clicked && !additive && len(ids) > 0 {
+		// the whole drag becomes a single undo step
+		g.history.begin("Drag", &g.sketch)
+		g.drag = pointDrag{
+			active:         true,
+			startMouse:     g.camera.InverseTransformPoint(mouse),
+			startPositions: map[int]geom.Vec2{},
+		}
+		for _, id := range ids {
+			point, err := sketch.GetElementByID[*sketch.SketchPoint](&g.sketch, id)
+			if err != nil {
+				continue
+			}
+			g.drag.startPositions[id] = point.Position
+		}
+	}
+
+	if !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		if g.drag.active {
+			g.history.end(&g.sketch)
+		}
+		g.drag.active = false
+		return
+	}
+	if !g.drag.active {
+		return
+	}
+
+	// offset from the drag start instead of the last frame so rejected moves don't accumulate drift
+	delta := g.camera.InverseTransformPoint(mouse).Sub(g.drag.startMouse)
+	targets := map[int]geom.Vec2{}
+	for id, position := range g.drag.startPositions {
+		targets[id] = position.Add(delta)
+	}
+	_, err := g.sketch.MovePoints(targets)
+	g.invalidateAnalysis()
+	if err != nil {
+		log.Printf("Drag failed: %v", err)
+		// keep what was dragged so far as its own undo step
+		g.history.end(&g.sketch)
+		g.drag.active = false
+	}
+}
@@ -0,0 +1,188 @@
+//go:build !headless
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"unholy-cad/geom"
+	"unholy-cad/sketch"
+	"unholy-cad/view"
+)
+
+const (
+	parameterPanelX     = 10.0
+	parameterPanelY     = 110.0
+	parameterRowHeight  = 18.0
+	parameterPanelWidth = 220.0
+)
+
+// textField is a one line text input that takes the keyboard while it is active
+type textField struct {
+	active bool
+	text   string
+	// error of the last submit, the field stays open until the text is fixed
+	err error
+}
+
+// update handles typing and returns true when the text is submitted with Enter.
+// Esc closes the field without submitting
+func (f *textField) update() bool {
+	f.text += string(ebiten.AppendInputChars(nil))
+	if repeatingKeyPressed(ebiten.KeyBackspace) && len(f.text) > 0 {
+		runes := []rune(f.text)
+		f.text = string(runes[:len(runes)-1])
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		*f = textField{}
+		return false
+	}
+	return inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadEnter)
+}
+
+// openCommandLine opens the text input at the bottom of the screen for assignments
+// such as "width = 40" or "d3 = 2*d1 + 5"
+func (g *Game) openCommandLine(text string) {
+	g.command = textField{active: true, text: text}
+	g.drag.active = false
+}
+
+// updateCommandLine handles typing while the command line is open
+func (g *Game) updateCommandLine() {
+	if !g.command.update() {
+		return
+	}
+	if err := g.assign(g.command.text); err != nil {
+		log.Printf("Could not apply %q: %v", g.command.text, err)
+		g.command.err = err
+		return
+	}
+	g.command = textField{}
+}
+
+// repeatingKeyPressed is true when a key was just pressed and then repeatedly while it is held
+func repeatingKeyPressed(key ebiten.Key) bool {
+	const (
+		delay    = 30
+		interval = 3
+	)
+	d := inpututil.KeyPressDuration(key)
+	return d == 1 || d >= delay && (d-delay)%interval == 0
+}
+
+// assign applies "name = expression" to the dimension with that name, or otherwise sets
+// the parameter. An empty expression removes a parameter or turns a dimension back into
+// a plain number. The sketch is re-solved afterwards and the change is reverted when
+// there is no solution
+func (g *Game) assign(text string) error {
+	name, expression, ok := strings.Cut(text, "=")
+	name = strings.TrimSpace(name)
+	expression = strings.TrimSpace(expression)
+	if !ok || name == "" {
+		return errors.New("expected name = expression")
+	}
+
+	return g.editAndSolve("Set "+name, func() error {
+		if d := g.sketch.GetDimensionByName(name); d != nil {
+			return g.sketch.SetDimensionExpression(d.GetId(), expression)
+		} else if expression == "" {
+			return g.sketch.RemoveParameter(name)
+		}
+		return g.sketch.SetParameter(name, expression)
+	})
+}
+
+// parameterRow is one line of the parameter panel, command is put into the command
+// line when the row is clicked
+type parameterRow struct {
+	text    string
+	command string
+}
+
+// parameterRows lists the parameters followed by the named dimensions of the sketch
+func (g *Game) parameterRows() []parameterRow {
+	rows := []parameterRow{{text: "Parameters (= to add)"}}
+	for _, p := range g.sketch.Parameters {
+		row := parameterRow{text: p.Name + " = " + p.Expression, command: p.Name + " = " + p.Expression}
+		if _, err := strconv.ParseFloat(p.Expression, 64); err != nil {
+			row.text += fmt.Sprintf(" = %.2f", p.Value)
+		}
+		rows = append(rows, row)
+	}
+	for _, d := range g.sketch.GetDimensions() {
+		expression := d.GetExpression()
+		if expression == "" {
+			expression = fmt.Sprint(roundDimension(d.GetValue()))
+		}
+		rows = append(rows, parameterRow{
+			text:    g.renderer().DimensionLabel(d.GetName()+": ", d, "%.2f"),
+			command: d.GetName() + " = " + expression,
+		})
+	}
+	return rows
+}
+
+func parameterRowPosition(i int) geom.Vec2 {
+	return geom.Vec2{X: parameterPanelX, Y: parameterPanelY + float64(i)*parameterRowHeight}
+}
+
+// parameterPanelHit returns the panel row under a screen position
+func (g *Game) parameterPanelHit(screenPos geom.Vec2) (parameterRow, bool) {
+	for i, row := range g.parameterRows() {
+		position := parameterRowPosition(i)
+		if screenPos.X >= position.X && screenPos.X <= position.X+parameterPanelWidth && screenPos.Y >= position.Y && screenPos.Y < position.Y+parameterRowHeight {
+			return row, true
+		}
+	}
+	return parameterRow{}, false
+}
+
+// updateParameterPanel opens the command line with "=" or by clicking a panel row.
+// Returns true when the click was used by the panel
+func (g *Game) updateParameterPanel(mouse geom.Vec2) bool {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEqual) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadEqual) {
+		g.openCommandLine("")
+		return true
+	}
+	if !inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		return false
+	}
+	row, ok := g.parameterPanelHit(mouse)
+	if !ok {
+		return false
+	}
+	g.openCommandLine(row.command)
+	return true
+}
+
+func (g *Game) drawParameterPanel(screen *ebiten.Image) {
+	for i, row := range g.parameterRows() {
+		col := color.RGBA{0x11, 0x11, 0x11, 0xFF}
+		if i == 0 {
+			col = color.RGBA{0x66, 0x66, 0x66, 0xFF}
+		}
+		DrawText(screen, row.text, parameterRowPosition(i), col)
+	}
+}
+
+func (g *Game) drawCommandLine(screen *ebiten.Image) {
+	if !g.command.active {
+		return
+	}
+	height := 24.0
+	top := float64(screenHeight) - height
+	vector.DrawFilledRect(screen, 0, float32(top), screenWidth, float32(height), color.RGBA{0xEE, 0xEE, 0xEE, 0xFF}, false)
+	DrawText(screen, "> "+g.command.text+"_", geom.Vec2{X: 10, Y: top + 3}, color.RGBA{0x11, 0x11, 0x11, 0xFF})
+	if g.command.err != nil {
+		DrawText(screen, g.command.err.Error(), geom.Vec2{X: 10, Y: top - 20}, view.StateColor(sketch.OverConstrained))
+	}
+}
@@ -0,0 +1,74 @@
+//go:build !headless
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"unholy-cad/geom"
+)
+
+const (
+	propertiesPanelY = toolbarY + toolbarHeight + 10
+)
+
+// hoveredRegion returns the index of the region under a screen position, or -1
+func (g *Game) hoveredRegion(screenPos geom.Vec2) int {
+	world := g.camera.InverseTransformPoint(screenPos)
+	for i, region := range g.topology.Regions {
+		inside, err := region.Contains(&g.sketch, world)
+		if err != nil {
+			log.Printf("Region %d could not be tested: %v", i, err)
+			continue
+		}
+		if inside {
+			return i
+		}
+	}
+	return -1
+}
+
+// propertiesRows lists the section properties of the region under the cursor
+func (g *Game) propertiesRows(screenPos geom.Vec2) []string {
+	if len(g.topology.Regions) == 0 {
+		return nil
+	}
+	i := g.hoveredRegion(screenPos)
+	if i < 0 {
+		return []string{fmt.Sprintf("%d regions, hover for properties", len(g.topology.Regions))}
+	}
+	region := g.topology.Regions[i]
+	p, err := region.Measure(&g.sketch)
+	if err != nil {
+		return []string{fmt.Sprintf("Region %d: %v", i+1, err)}
+	}
+	title := fmt.Sprintf("Region %d", i+1)
+	if len(region.Holes) > 0 {
+		title += fmt.Sprintf(" (%d holes)", len(region.Holes))
+	}
+	return []string{
+		title,
+		fmt.Sprintf("A = %.2f  P = %.2f", p.Area, p.Perimeter),
+		fmt.Sprintf("C = (%.2f, %.2f)", p.Centroid.X, p.Centroid.Y),
+		fmt.Sprintf("Ixx = %.2f  Iyy = %.2f", p.Ixx, p.Iyy),
+		fmt.Sprintf("Ixy = %.2f  J = %.2f", p.Ixy, p.J),
+	}
+}
+
+// drawPropertiesPanel shows the section properties right aligned below the toolbar
+func (g *Game) drawPropertiesPanel(screen *ebiten.Image) {
+	mouseX, mouseY := ebiten.CursorPosition()
+	rows := g.propertiesRows(geom.Vec2{X: float64(mouseX), Y: float64(mouseY)})
+	for i, row := range rows {
+		col := color.RGBA{0x11, 0x11, 0x11, 0xFF}
+		if i == 0 {
+			col = color.RGBA{0x66, 0x66, 0x66, 0xFF}
+		}
+		position := geom.Vec2{X: float64(screenWidth) - 10 - MeasureText(row), Y: propertiesPanelY + float64(i)*parameterRowHeight}
+		DrawText(screen, row, position, col)
+	}
+}
@@ -0,0 +1,153 @@
+//go:build !headless
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"unholy-cad/geom"
+	"unholy-cad/sketch"
+	"unholy-cad/view"
+)
+
+// screenCanvas draws onto the ebiten screen
+type screenCanvas struct {
+	*ebiten.Image
+}
+
+func (c screenCanvas) StrokeLine(p1, p2 geom.Vec2, thickness float64, col color.Color) {
+	vector.StrokeLine(c.Image, float32(p1.X), float32(p1.Y), float32(p2.X), float32(p2.Y), float32(thickness), col, true)
+}
+
+func (c screenCanvas) StrokeCircle(center geom.Vec2, radius, thickness float64, col color.Color) {
+	vector.StrokeCircle(c.Image, float32(center.X), float32(center.Y), float32(radius), float32(thickness), col, true)
+}
+
+func (c screenCanvas) StrokeArc(center geom.Vec2, radius, startAngle, sweep, thickness float64, col color.Color) {
+	// roughly one segment every 4 pixels
+	segments := int(math.Max(8, math.Ceil(math.Abs(sweep)*radius/4)))
+	previous := center.Add(geom.Vec2{X: math.Cos(startAngle), Y: math.Sin(startAngle)}.Mul(radius))
+	for i := 1; i <= segments; i++ {
+		angle := startAngle + sweep*float64(i)/float64(segments)
+		next := center.Add(geom.Vec2{X: math.Cos(angle), Y: math.Sin(angle)}.Mul(radius))
+		c.StrokeLine(previous, next, thickness, col)
+		previous = next
+	}
+}
+
+// whiteImage is the source of filled polygons, the vertex colors tint it. It is created
+// on first use, so the command line tools do not need a graphics context
+var whiteImage *ebiten.Image
+
+func (c screenCanvas) FillPolygons(polygons [][]geom.Vec2, col color.Color) {
+	var path vector.Path
+	for _, polygon := range polygons {
+		for i, p := range polygon {
+			if i == 0 {
+				path.MoveTo(float32(p.X), float32(p.Y))
+			} else {
+				path.LineTo(float32(p.X), float32(p.Y))
+			}
+		}
+		path.Close()
+	}
+
+	if whiteImage == nil {
+		img := ebiten.NewImage(3, 3)
+		img.Fill(color.White)
+		whiteImage = img.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+	}
+
+	vertices, indices := path.AppendVerticesAndIndicesForFilling(nil, nil)
+	r, g, b, a := col.RGBA()
+	for i := range vertices {
+		vertices[i].SrcX, vertices[i].SrcY = 1, 1
+		vertices[i].ColorR = float32(r) / 0xffff
+		vertices[i].ColorG = float32(g) / 0xffff
+		vertices[i].ColorB = float32(b) / 0xffff
+		vertices[i].ColorA = float32(a) / 0xffff
+	}
+	op := &ebiten.DrawTrianglesOptions{
+		ColorScaleMode: ebiten.ColorScaleModePremultipliedAlpha,
+		AntiAlias:      true,
+		FillRule:       ebiten.NonZero,
+	}
+	c.Image.DrawTriangles(vertices, indices, whiteImage, op)
+}
+
+func (c screenCanvas) DrawText(text string, position geom.Vec2, col color.Color) {
+	DrawText(c.Image, text, position, col)
+}
+
+func (c screenCanvas) MeasureText(text string) float64 {
+	return MeasureText(text)
+}
+
+// renderer draws the sketch with the hover, selection and editor state of the game
+func (g *Game) renderer() *view.Renderer {
+	r := view.NewRenderer(&g.sketch, g.camera, screenWidth, screenHeight)
+	r.Dof = g.dof
+	r.HoverId = g.hoverId
+	r.Selection = g.selection
+	r.Conflicts = g.dimensionEditor.conflicts
+	r.Topology = &g.topology
+	if g.dimensionEditor.active {
+		r.EditingId = g.dimensionEditor.dimensionId
+	}
+	return r
+}
+
+func (g *Game) drawDofStatus(screen *ebiten.Image) {
+	var status string
+	switch g.dof.GetState() {
+	case sketch.OverConstrained:
+		status = "Over constrained"
+	case sketch.UnderConstrained:
+		status = "Under constrained"
+	default:
+		status = "Fully constrained"
+	}
+
+	DrawText(screen, fmt.Sprintf("DOF: %d - %s", g.dof.Dof, status), geom.Vec2{X: 10, Y: 10}, view.StateColor(g.dof.GetState()))
+
+	y := 30.0
+	if len(g.dof.Redundant) > 0 {
+		DrawText(screen, "Redundant: "+joinIds(g.dof.Redundant), geom.Vec2{X: 10, Y: y}, view.StateColor(sketch.OverConstrained))
+		y += 20
+	}
+	if len(g.dof.Conflicting) > 0 {
+		DrawText(screen, "Conflicting: "+joinIds(g.dof.Conflicting), geom.Vec2{X: 10, Y: y}, view.StateColor(sketch.OverConstrained))
+		y += 20
+	}
+	if len(g.dof.Invalid) > 0 {
+		invalid := make([]int, 0, len(g.dof.Invalid))
+		for id := range g.dof.Invalid {
+			invalid = append(invalid, id)
+		}
+		sort.Ints(invalid)
+		DrawText(screen, "Invalid: "+joinIds(invalid), geom.Vec2{X: 10, Y: y}, view.StateColor(sketch.OverConstrained))
+	}
+}
+
+// drawErrors lists the elements that could not be drawn at the bottom of the screen
+func (g *Game) drawErrors(screen *ebiten.Image, errs []error) {
+	for i, err := range errs {
+		DrawText(screen, err.Error(), geom.Vec2{X: 10, Y: float64(screenHeight - 25 - 20*(len(errs)-1-i))}, view.StateColor(sketch.OverConstrained))
+	}
+}
+
+func joinIds(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = fmt.Sprint(id)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,181 @@
+// Package render draws sketches into images on the CPU, without a window or a GPU. The
+// images are the same on every machine, so tests can compare them with golden images
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+
+	"unholy-cad/geom"
+	"unholy-cad/view"
+)
+
+// Canvas rasterizes onto an RGBA image with antialiasing
+type Canvas struct {
+	Image      *image.RGBA
+	face       font.Face
+	rasterizer vector.Rasterizer
+}
+
+// NewCanvas returns a white canvas of a size in pixels
+func NewCanvas(width, height int) (*Canvas, error) {
+	face, err := view.NewFace(view.FontSize)
+	if err != nil {
+		return nil, err
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	return &Canvas{Image: img, face: face}, nil
+}
+
+// Close releases the font of the canvas
+func (c *Canvas) Close() error {
+	return c.face.Close()
+}
+
+// Render draws a sketch the way the renderer is set up, onto a white image of the
+// renderer's size. Elements that cannot be drawn are left out
+func Render(r *view.Renderer) (*image.RGBA, error) {
+	c, err := NewCanvas(int(math.Ceil(r.Width)), int(math.Ceil(r.Height)))
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	r.Draw(c)
+	return c.Image, nil
+}
+
+// fill fills the polygons with the nonzero rule, so a polygon running the other way
+// inside another one cuts a hole into it
+func (c *Canvas) fill(col color.Color, polygons ...[]geom.Vec2) {
+	// only rasterize the pixels the polygons cover
+	lower := geom.Vec2{X: math.Inf(1), Y: math.Inf(1)}
+	upper := geom.Vec2{X: math.Inf(-1), Y: math.Inf(-1)}
+	for _, polygon := range polygons {
+		for _, p := range polygon {
+			lower = geom.Vec2{X: math.Min(lower.X, p.X), Y: math.Min(lower.Y, p.Y)}
+			upper = geom.Vec2{X: math.Max(upper.X, p.X), Y: math.Max(upper.Y, p.Y)}
+		}
+	}
+	if math.IsInf(lower.X, 0) || math.IsNaN(lower.X+lower.Y+upper.X+upper.Y) {
+		return
+	}
+	area := image.Rect(int(math.Floor(lower.X)), int(math.Floor(lower.Y)), int(math.Ceil(upper.X)), int(math.Ceil(upper.Y))).
+		Intersect(c.Image.Bounds())
+	if area.Empty() {
+		return
+	}
+
+	c.rasterizer.Reset(area.Dx(), area.Dy())
+	for _, polygon := range polygons {
+		if len(polygon) < 3 {
+			continue
+		}
+		c.rasterizer.MoveTo(float32(polygon[0].X)-float32(area.Min.X), float32(polygon[0].Y)-float32(area.Min.Y))
+		for _, p := range polygon[1:] {
+			c.rasterizer.LineTo(float32(p.X)-float32(area.Min.X), float32(p.Y)-float32(area.Min.Y))
+		}
+		c.rasterizer.ClosePath()
+	}
+	c.rasterizer.Draw(c.Image, area, image.NewUniform(col), image.Point{})
+}
+
+func (c *Canvas) StrokeLine(p1, p2 geom.Vec2, thickness float64, col color.Color) {
+	direction := p2.Sub(p1)
+	if direction.Magnitude() == 0 {
+		return
+	}
+	side := direction.Tangent().Normalize().Mul(thickness / 2)
+	c.fill(col, []geom.Vec2{p1.Add(side), p2.Add(side), p2.Sub(side), p1.Sub(side)})
+}
+
+func (c *Canvas) StrokeCircle(center geom.Vec2, radius, thickness float64, col color.Color) {
+	c.StrokeArc(center, radius, 0, 2*math.Pi, thickness, col)
+}
+
+// StrokeArc fills the band between two arcs half the thickness inside and outside the radius
+func (c *Canvas) StrokeArc(center geom.Vec2, radius, startAngle, sweep, thickness float64, col color.Color) {
+	outer := arcPoints(center, radius+thickness/2, startAngle, sweep)
+	inner := arcPoints(center, math.Max(0, radius-thickness/2), startAngle, sweep)
+	reverse(inner)
+	if math.Abs(sweep) >= 2*math.Pi {
+		// a ring, the inner circle runs backwards to leave it empty
+		c.fill(col, outer, inner)
+		return
+	}
+	c.fill(col, append(outer, inner...))
+}
+
+// arcPoints returns points along an arc, roughly one every 4 pixels
+func arcPoints(center geom.Vec2, radius, startAngle, sweep float64) []geom.Vec2 {
+	segments := int(math.Max(8, math.Ceil(math.Abs(sweep)*radius/4)))
+	points := make([]geom.Vec2, 0, segments+1)
+	for i := 0; i <= segments; i++ {
+		angle := startAngle + sweep*float64(i)/float64(segments)
+		points = append(points, center.Add(geom.Vec2{X: math.Cos(angle), Y: math.Sin(angle)}.Mul(radius)))
+	}
+	return points
+}
+
+func reverse(points []geom.Vec2) {
+	for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+		points[i], points[j] = points[j], points[i]
+	}
+}
+
+func (c *Canvas) FillPolygons(polygons [][]geom.Vec2, col color.Color) {
+	c.fill(col, polygons...)
+}
+
+func (c *Canvas) DrawText(text string, position geom.Vec2, col color.Color) {
+	drawer := &font.Drawer{
+		Dst:  c.Image,
+		Src:  image.NewUniform(col),
+		Face: c.face,
+		Dot:  fixed.P(int(math.Round(position.X)), int(math.Round(position.Y))+c.face.Metrics().Ascent.Round()),
+	}
+	drawer.DrawString(text)
+}
+
+func (c *Canvas) MeasureText(text string) float64 {
+	return float64(font.MeasureString(c.face, text).Round())
+}
+
+// Diff counts the pixels where a channel of the two images differs by more than the
+// threshold, out of 255. Images of different sizes differ everywhere
+func Diff(a, b image.Image, threshold uint8) int {
+	if a.Bounds().Size() != b.Bounds().Size() {
+		size := a.Bounds().Size()
+		if other := b.Bounds().Size(); other.X*other.Y > size.X*size.Y {
+			size = other
+		}
+		return size.X * size.Y
+	}
+
+	limit := uint32(threshold) * 0x101
+	differing := 0
+	offset := b.Bounds().Min.Sub(a.Bounds().Min)
+	for y := a.Bounds().Min.Y; y < a.Bounds().Max.Y; y++ {
+		for x := a.Bounds().Min.X; x < a.Bounds().Max.X; x++ {
+			r1, g1, b1, a1 := a.At(x, y).RGBA()
+			r2, g2, b2, a2 := b.At(x+offset.X, y+offset.Y).RGBA()
+			if difference(r1, r2) > limit || difference(g1, g2) > limit || difference(b1, b2) > limit || difference(a1, a2) > limit {
+				differing++
+			}
+		}
+	}
+	return differing
+}
+
+func difference(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,161 @@
+package render
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"unholy-cad/geom"
+	"unholy-cad/sketch"
+	"unholy-cad/view"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images in testdata")
+
+// glyphSketch has one of every constraint and dimension, all satisfied
+func glyphSketch() *sketch.Sketch {
+	s := sketch.NewSketch()
+	constrain := func(c sketch.SketchElement) {
+		s.Elements = append(s.Elements, c)
+	}
+	line := func(start, end *sketch.SketchPoint) *sketch.SketchLine {
+		return s.AddLine(start.Id, end.Id)
+	}
+	point := func(x, y float64) *sketch.SketchPoint {
+		return s.AddPoint(geom.Vec2{X: x, Y: y})
+	}
+
+	// rectangle
+	a, b, c, d := point(0, 0), point(10, 0), point(10, 8), point(0, 8)
+	ab, bc, cd := line(a, b), line(b, c), line(c, d)
+	line(d, a)
+	constrain(&sketch.SketchConstraintFixed{Id: s.NextId(), PointId: a.Id, Position: a.Position})
+	constrain(&sketch.SketchConstraintVertical{Id: s.NextId(), Point1Id: b.Id, Point2Id: c.Id, LineId: &bc.Id})
+	constrain(&sketch.SketchConstraintParallel{Id: s.NextId(), Line1Id: ab.Id, Line2Id: cd.Id})
+	constrain(&sketch.SketchConstraintCornerAngle{Id: s.NextId(), CornerPointId: d.Id, LinePoint1Id: a.Id, LinePoint2Id: c.Id, Angle: 90})
+	constrain(&sketch.SketchConstraintLineLength{Id: s.NextId(), LineId: ab.Id, Length: 10})
+	// clockwise right angles get the mark too
+	constrain(&sketch.SketchConstraintCornerAngle{Id: s.NextId(), CornerPointId: b.Id, LinePoint1Id: a.Id, LinePoint2Id: c.Id, Angle: 270})
+	n := point(3, 0)
+	constrain(&sketch.SketchConstraintPointOnLine{Id: s.NextId(), PointId: n.Id, LineId: ab.Id})
+	constrain(&sketch.SketchConstraintPointDistance{Id: s.NextId(), Point1Id: n.Id, Point2Id: d.Id, Direction: sketch.DistanceVertical, Distance: 8})
+
+	// right triangle next to the rectangle
+	e, f, g := point(14, 0), point(22, 0), point(14, 8)
+	ef, fg, ge := line(e, f), line(f, g), line(g, e)
+	constrain(&sketch.SketchConstraintPointDistance{Id: s.NextId(), Point1Id: b.Id, Point2Id: e.Id, Direction: sketch.DistanceHorizontal, Distance: 4})
+	constrain(&sketch.SketchConstraintPerpendicular{Id: s.NextId(), Line1Id: ef.Id, Line2Id: ge.Id})
+	constrain(&sketch.SketchConstraintCornerAngle{Id: s.NextId(), CornerPointId: f.Id, LinePoint1Id: g.Id, LinePoint2Id: e.Id, Angle: 45})
+	constrain(&sketch.SketchConstraintPointDistance{Id: s.NextId(), Point1Id: c.Id, Point2Id: f.Id, Direction: sketch.DistanceAligned, Distance: math.Hypot(12, 8)})
+
+	// points mirrored about a line
+	axis := line(point(34, 0), point(34, 8))
+	s1, s2 := point(31, 4), point(37, 4)
+	constrain(&sketch.SketchConstraintSymmetric{Id: s.NextId(), Point1Id: s1.Id, Point2Id: s2.Id, LineId: axis.Id})
+	constrain(&sketch.SketchConstraintPointLineDistance{Id: s.NextId(), PointId: s1.Id, LineId: axis.Id, Distance: 3})
+	middle := point(34, 4)
+	constrain(&sketch.SketchConstraintMidpoint{Id: s.NextId(), PointId: middle.Id, LineId: axis.Id})
+	constrain(&sketch.SketchConstraintLineAngle{Id: s.NextId(), Line1Id: axis.Id, Line2Id: fg.Id, Angle: 45})
+
+	// circles and a tangent line
+	circle1 := s.AddCircle(point(5, 18).Id, 3)
+	circle2 := s.AddCircle(point(5, 18).Id, 5)
+	constrain(&sketch.SketchConstraintConcentric{Id: s.NextId(), Curve1Id: circle1.Id, Curve2Id: circle2.Id})
+	constrain(&sketch.SketchConstraintRadius{Id: s.NextId(), CurveId: circle1.Id, Radius: 3})
+	constrain(&sketch.SketchConstraintDiameter{Id: s.NextId(), CurveId: circle2.Id, Diameter: 10})
+	on := point(5, 23)
+	constrain(&sketch.SketchConstraintPointOnCurve{Id: s.NextId(), PointId: on.Id, CurveId: circle2.Id})
+	tangentStart, tangentEnd := point(-2, 21), point(16, 21)
+	tangent := line(tangentStart, tangentEnd)
+	constrain(&sketch.SketchConstraintTangent{Id: s.NextId(), LineId: tangent.Id, CurveId: circle1.Id})
+	constrain(&sketch.SketchConstraintHorizontal{Id: s.NextId(), Point1Id: tangentStart.Id, Point2Id: tangentEnd.Id, LineId: &tangent.Id})
+	joined := point(16, 21)
+	line(joined, point(16, 17))
+	constrain(&sketch.SketchConstraintCoincident{Id: s.NextId(), Point1Id: tangentEnd.Id, Point2Id: joined.Id})
+	referenceLength := &sketch.SketchConstraintLineLength{Id: s.NextId(), LineId: tangent.Id, Length: 18}
+	referenceLength.SetReference(true)
+	constrain(referenceLength)
+
+	// arcs meeting at a shared point
+	shared := point(22, 18)
+	arc1 := s.AddArc(point(18, 18).Id, point(18, 14).Id, shared.Id)
+	arc2 := s.AddArc(point(26, 18).Id, point(26, 22).Id, shared.Id)
+	constrain(&sketch.SketchConstraintArcTangent{Id: s.NextId(), Arc1Id: arc1.Id, Arc2Id: arc2.Id})
+	constrain(&sketch.SketchConstraintEqual{Id: s.NextId(), Element1Id: arc1.Id, Element2Id: arc2.Id})
+	referenceRadius := &sketch.SketchConstraintRadius{Id: s.NextId(), CurveId: arc2.Id, Radius: 4}
+	referenceRadius.SetReference(true)
+	constrain(referenceRadius)
+	return s
+}
+
+func TestRenderGolden(t *testing.T) {
+	s := glyphSketch()
+	for _, constraint := range s.GetConstraints() {
+		satisfied, err := constraint.IsSatisfied(s)
+		if err != nil {
+			t.Fatalf("constraint %d: %v", constraint.GetId(), err)
+		}
+		if !satisfied {
+			t.Fatalf("constraint %d of the fixture is not satisfied", constraint.GetId())
+		}
+	}
+
+	camera := view.Camera{Position: geom.Vec2{X: -4, Y: -4}, Scale: 18, PixelSnap: true}
+	tests := []struct {
+		name  string
+		setup func(r *view.Renderer)
+	}{
+		{"glyphs", func(r *view.Renderer) {}},
+		{"selected", func(r *view.Renderer) {
+			// the top of the rectangle, and its right and bottom sides
+			r.HoverId = 4
+			r.Selection = []int{5, 6}
+		}},
+		{"geometry", func(r *view.Renderer) {
+			r.Options = view.Options{}
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := view.NewRenderer(s, camera, 800, 600)
+			if dof, err := s.AnalyzeDegreesOfFreedom(); err == nil {
+				r.Dof = dof
+			}
+			test.setup(r)
+			img, err := Render(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join("testdata", test.name+".png")
+			if *update {
+				var data bytes.Buffer
+				if err := png.Encode(&data, img); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, data.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("%v, run the tests with -update to create it", err)
+			}
+			defer file.Close()
+			golden, _, err := image.Decode(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if differing := Diff(img, golden, 8); differing > 0 {
+				t.Errorf("%d pixels differ from %s, run the tests with -update if the change is intended", differing, path)
+			}
+		})
+	}
+}
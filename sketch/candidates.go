@@ -0,0 +1,119 @@
+package sketch
+
+import (
+	"math"
+)
+
+// solveReference is the state of the sketch before a solve, candidate solutions are
+// compared against it
+type solveReference struct {
+	variables []float64
+	turns     map[corner]float64
+}
+
+// corner is a pair of lines meeting at a shared point
+type corner struct {
+	pointId, line1Id, line2Id int
+}
+
+// solveCandidate is a solution found by the solver and how far it is from the reference
+type solveCandidate struct {
+	elements []SketchElement
+	// corners that turn the other way than before, a mirrored shape flips all of them
+	flips int
+	// sum of the squared movement of every variable
+	displacement float64
+}
+
+// closerThan prefers solutions that keep the orientation of the corners, then the ones
+// that move the geometry the least
+func (c *solveCandidate) closerThan(other *solveCandidate) bool {
+	if other == nil {
+		return true
+	}
+	if c.flips != other.flips {
+		return c.flips < other.flips
+	}
+	return c.displacement < other.displacement
+}
+
+func newSolveReference(s *Sketch) *solveReference {
+	return &solveReference{
+		variables: getVariableValues(s),
+		turns:     getCornerTurns(s),
+	}
+}
+
+// candidate scores the current state of the sketch, which has to have the same
+// elements as the reference
+func (r *solveReference) candidate(s *Sketch) *solveCandidate {
+	c := &solveCandidate{elements: s.GetClonedElements()}
+	for i, value := range getVariableValues(s) {
+		delta := value - r.variables[i]
+		c.displacement += delta * delta
+	}
+	for key, turn := range getCornerTurns(s) {
+		if before := r.turns[key]; before != 0 && turn != 0 && turn != before {
+			c.flips++
+		}
+	}
+	return c
+}
+
+// getVariableValues returns the values of all variables in element order
+func getVariableValues(s *Sketch) []float64 {
+	values := make([]float64, 0)
+	for _, element := range s.Elements {
+		if v, ok := element.(SketchVariables); ok {
+			for _, variable := range v.getVariables() {
+				values = append(values, *variable)
+			}
+		}
+	}
+	return values
+}
+
+// getCornerTurns returns whether each corner turns left (1) or right (-1) from its first
+// line to the second one. Corners that are straight or folded back are 0
+func getCornerTurns(s *Sketch) map[corner]float64 {
+	linesAt := map[int][]*SketchLine{}
+	for _, element := range s.Elements {
+		if line, ok := element.(*SketchLine); ok && !IsBuiltin(line.Id) {
+			linesAt[line.StartId] = append(linesAt[line.StartId], line)
+			linesAt[line.EndId] = append(linesAt[line.EndId], line)
+		}
+	}
+
+	// direction away from the shared point
+	direction := func(line *SketchLine, pointId int) (float64, float64, bool) {
+		points, err := GetPoints(s, line.StartId, line.EndId)
+		if err != nil {
+			return 0, 0, false
+		}
+		v := points[1].Position.Sub(points[0].Position)
+		if line.EndId == pointId {
+			v = v.Mul(-1)
+		}
+		return v.X, v.Y, true
+	}
+
+	turns := map[corner]float64{}
+	for pointId, lines := range linesAt {
+		for i := 0; i < len(lines); i++ {
+			for j := i + 1; j < len(lines); j++ {
+				x1, y1, ok1 := direction(lines[i], pointId)
+				x2, y2, ok2 := direction(lines[j], pointId)
+				if !ok1 || !ok2 {
+					continue
+				}
+				cross := x1*y2 - y1*x2
+				turn := 0.0
+				if math.Abs(cross) > 1e-9*math.Hypot(x1, y1)*math.Hypot(x2, y2) {
+					turn = math.Copysign(1, cross)
+				}
+				turns[corner{pointId: pointId, line1Id: lines[i].Id, line2Id: lines[j].Id}] = turn
+			}
+		}
+	}
+	return turns
+}
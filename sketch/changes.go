@@ -0,0 +1,137 @@
+package sketch
+
+import (
+	"reflect"
+)
+
+// elementChange is the state of one element before and after an edit, nil where the
+// element does not exist
+type elementChange struct {
+	id            int
+	before, after SketchElement
+}
+
+// Changes records what an edit did to a sketch, only the elements and parameters it
+// created, deleted or modified are kept, so it can be undone and redone
+type Changes struct {
+	elements []elementChange
+	// element ids in order, only kept when the edit added, removed or reordered elements
+	orderBefore, orderAfter []int
+	// parameters, only kept when the edit changed them
+	parametersChanged                 bool
+	parametersBefore, parametersAfter []*Parameter
+}
+
+func elementOrder(s *Sketch) []int {
+	order := make([]int, len(s.Elements))
+	for i, element := range s.Elements {
+		order[i] = element.GetId()
+	}
+	return order
+}
+
+// Diff returns the changes that turn one sketch into the other. The elements are cloned,
+// so later edits of either sketch do not affect the changes
+func Diff(before, after *Sketch) *Changes {
+	c := &Changes{}
+	previous := map[int]SketchElement{}
+	for _, element := range before.Elements {
+		previous[element.GetId()] = element
+	}
+	for _, element := range after.Elements {
+		old, existed := previous[element.GetId()]
+		delete(previous, element.GetId())
+		if existed && reflect.DeepEqual(old, element) {
+			continue
+		}
+		change := elementChange{id: element.GetId(), after: element.Clone()}
+		if existed {
+			change.before = old.Clone()
+		}
+		c.elements = append(c.elements, change)
+	}
+	// whatever is left was deleted, in the order it had
+	for _, element := range before.Elements {
+		if old, deleted := previous[element.GetId()]; deleted {
+			c.elements = append(c.elements, elementChange{id: old.GetId(), before: old.Clone()})
+		}
+	}
+
+	if orderBefore, orderAfter := elementOrder(before), elementOrder(after); !reflect.DeepEqual(orderBefore, orderAfter) {
+		c.orderBefore, c.orderAfter = orderBefore, orderAfter
+	}
+	if !reflect.DeepEqual(before.Parameters, after.Parameters) {
+		c.parametersChanged = true
+		c.parametersBefore, c.parametersAfter = before.cloneParameters(), after.cloneParameters()
+	}
+	return c
+}
+
+// Empty is true when the edit changed nothing
+func (c *Changes) Empty() bool {
+	return len(c.elements) == 0 && c.orderBefore == nil && !c.parametersChanged
+}
+
+// Size is the number of element states the changes hold
+func (c *Changes) Size() int {
+	size := len(c.parametersBefore) + len(c.parametersAfter)
+	for _, change := range c.elements {
+		if change.before != nil {
+			size++
+		}
+		if change.after != nil {
+			size++
+		}
+	}
+	return size
+}
+
+// Undo puts the changed elements and parameters back to their state before the edit
+func (c *Changes) Undo(s *Sketch) {
+	c.apply(s, false)
+}
+
+// Redo applies the edit again to a sketch it was undone on
+func (c *Changes) Redo(s *Sketch) {
+	c.apply(s, true)
+}
+
+func (c *Changes) apply(s *Sketch, forward bool) {
+	elements := map[int]SketchElement{}
+	for _, element := range s.Elements {
+		elements[element.GetId()] = element
+	}
+	for _, change := range c.elements {
+		state := change.before
+		if forward {
+			state = change.after
+		}
+		if state == nil {
+			delete(elements, change.id)
+		} else {
+			elements[change.id] = state.Clone()
+		}
+	}
+
+	order := c.orderBefore
+	if forward {
+		order = c.orderAfter
+	}
+	if order == nil {
+		order = elementOrder(s)
+	}
+	s.Elements = make([]SketchElement, 0, len(order))
+	for _, id := range order {
+		if element, ok := elements[id]; ok {
+			s.Elements = append(s.Elements, element)
+		}
+	}
+
+	if c.parametersChanged {
+		parameters := c.parametersBefore
+		if forward {
+			parameters = c.parametersAfter
+		}
+		s.Parameters = (&Sketch{Parameters: parameters}).cloneParameters()
+	}
+}
@@ -0,0 +1,74 @@
+package sketch
+
+import (
+	"reflect"
+	"testing"
+
+	"unholy-cad/geom"
+)
+
+func TestChangesUndoRedo(t *testing.T) {
+	base := func() *Sketch {
+		s := NewSketch()
+		a := s.AddPoint(geom.Vec2{X: 0, Y: 0})
+		b := s.AddPoint(geom.Vec2{X: 10, Y: 0})
+		c := s.AddPoint(geom.Vec2{X: 10, Y: 5})
+		line := s.AddLine(a.Id, b.Id)
+		s.AddLine(b.Id, c.Id)
+		s.Elements = append(s.Elements, &SketchConstraintLineLength{Id: s.NextId(), LineId: line.Id, Length: 10})
+		return s
+	}
+
+	tests := []struct {
+		name string
+		edit func(s *Sketch)
+		// element states the changes should hold
+		size int
+	}{
+		{"move", func(s *Sketch) {
+			point, _ := GetElementByID[*SketchPoint](s, 2)
+			point.Position = geom.Vec2{X: 12, Y: 6}
+		}, 2},
+		{"add", func(s *Sketch) {
+			s.AddLine(2, s.AddPoint(geom.Vec2{X: 0, Y: 5}).Id)
+		}, 2},
+		{"delete", func(s *Sketch) {
+			// the point takes its line and the length of the line with it
+			s.DeleteElement(0)
+		}, 3},
+		{"parameter", func(s *Sketch) {
+			if err := s.SetParameter("w", "10"); err != nil {
+				t.Fatal(err)
+			}
+		}, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := base()
+			s := before.Clone()
+			test.edit(s)
+			after := s.Clone()
+
+			changes := Diff(before, s)
+			if changes.Empty() {
+				t.Fatal("changes are empty")
+			}
+			if size := changes.Size(); size != test.size {
+				t.Errorf("changes hold %d element states, want %d", size, test.size)
+			}
+
+			changes.Undo(s)
+			if !reflect.DeepEqual(s, before) {
+				t.Errorf("undo gave %+v, want %+v", s, before)
+			}
+			changes.Redo(s)
+			if !reflect.DeepEqual(s, after) {
+				t.Errorf("redo gave %+v, want %+v", s, after)
+			}
+		})
+	}
+
+	if changes := Diff(base(), base()); !changes.Empty() {
+		t.Errorf("identical sketches differ: %+v", changes)
+	}
+}
@@ -0,0 +1,325 @@
+package sketch
+
+import (
+	"math"
+)
+
+type SketchConstraint interface {
+	SketchElement
+	GetBranches() int
+	Apply(s *Sketch, branch int) (bool, error)
+	IsSatisfied(s *Sketch) (bool, error)
+	// ids of the elements whose variables the residuals depend on
+	GetDependencies(s *Sketch) ([]int, error)
+	// residual equations for the numeric solver, all zero when the constraint is satisfied
+	GetResiduals(s *Sketch) ([]float64, error)
+}
+
+// SketchImplicit is geometry that ties its own points together, like the endpoints of an
+// arc that stay on one circle. The solver satisfies its implicit constraints along with the
+// constraints of the sketch, but they are not listed or reported as constraints
+type SketchImplicit interface {
+	SketchElement
+	GetImplicitConstraints() []SketchConstraint
+}
+
+// isImplicit is true for a constraint that comes from geometry instead of the sketch
+func isImplicit(constraint SketchConstraint) bool {
+	_, ok := constraint.(*arcRadius)
+	return ok
+}
+
+type SketchConstraintCornerAngle struct {
+	Id            int     `json:"id"`
+	CornerPointId int     `json:"cornerPointId"`
+	LinePoint1Id  int     `json:"linePoint1Id"`
+	LinePoint2Id  int     `json:"linePoint2Id"`
+	Angle         float64 `json:"angle"`
+	dimension
+}
+
+func (c *SketchConstraintCornerAngle) Clone() SketchElement {
+	return &SketchConstraintCornerAngle{
+		Id:            c.Id,
+		CornerPointId: c.CornerPointId,
+		LinePoint1Id:  c.LinePoint1Id,
+		LinePoint2Id:  c.LinePoint2Id,
+		Angle:         c.Angle,
+		dimension:     c.dimension,
+	}
+}
+
+// GetPoints resolves the corner point and the far points of both lines
+func (c *SketchConstraintCornerAngle) GetPoints(s *Sketch) (*SketchPoint, *SketchPoint, *SketchPoint, error) {
+	cornerPoint, err := GetElementByID[*SketchPoint](s, c.CornerPointId)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	linePoint1, err := GetElementByID[*SketchPoint](s, c.LinePoint1Id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	linePoint2, err := GetElementByID[*SketchPoint](s, c.LinePoint2Id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return cornerPoint, linePoint1, linePoint2, nil
+}
+
+// GetCurrentAngle returns the angle at the corner from line1 to line2 in degrees, between
+// 0 and 360 and turning the same way as a line angle, so obtuse and reflex corners can be told apart
+func (c *SketchConstraintCornerAngle) GetCurrentAngle(s *Sketch) (float64, error) {
+	signed, err := c.getSignedAngle(s)
+	if err != nil {
+		return 0, err
+	}
+	degrees := signed * 180 / math.Pi
+	if degrees < 0 {
+		degrees += 360
+	}
+	return degrees, nil
+}
+
+// getSignedAngle returns the angle from line1 to line2 around the corner in radians
+func (c *SketchConstraintCornerAngle) getSignedAngle(s *Sketch) (float64, error) {
+	cornerPoint, linePoint1, linePoint2, err := c.GetPoints(s)
+	if err != nil {
+		return 0, err
+	}
+
+	v1 := linePoint1.Position.Sub(cornerPoint.Position)
+	v2 := linePoint2.Position.Sub(cornerPoint.Position)
+
+	// atan2 stays well conditioned near 0° and 180° where acos does not
+	return math.Atan2(v1.Cross(v2), v1.Dot(v2)), nil
+}
+
+func (c *SketchConstraintCornerAngle) GetDependencies(s *Sketch) ([]int, error) {
+	return []int{c.CornerPointId, c.LinePoint1Id, c.LinePoint2Id}, nil
+}
+
+func (c *SketchConstraintCornerAngle) GetResiduals(s *Sketch) ([]float64, error) {
+	signed, err := c.getSignedAngle(s)
+	if err != nil {
+		return nil, err
+	}
+	// wrapped like the line angle, 350° and -10° are the same corner
+	return []float64{wrapAngle(signed - c.Angle*math.Pi/180)}, nil
+}
+
+func (c *SketchConstraintCornerAngle) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(residuals[0]), nil
+}
+
+func (c *SketchConstraintCornerAngle) GetBranches() int {
+	return 6
+}
+
+func (cc *SketchConstraintCornerAngle) Apply(s *Sketch, branch int) (bool, error) {
+	cornerPoint, linePoint1, linePoint2, err := cc.GetPoints(s)
+	if err != nil {
+		return false, err
+	}
+
+	signed, err := cc.getSignedAngle(s)
+	if err != nil {
+		return false, err
+	}
+	target := wrapAngle(cc.Angle * math.Pi / 180)
+	// the other branches keep the side line2 is on relative to line1 and only change the
+	// opening of the corner, which is the target angle without its sign
+	radians := math.Abs(target)
+	if branch >= 2 && (signed < 0) != (target < 0) {
+		return false, nil
+	}
+
+	if branch == 0 || branch == 1 {
+		// rotate one of the lines around the corner point
+		offset := wrapAngle(signed - target)
+		if branch == 0 {
+			linePoint1.Position = linePoint1.Position.RotateAround(cornerPoint.Position, offset)
+		} else {
+			linePoint2.Position = linePoint2.Position.RotateAround(cornerPoint.Position, -offset)
+		}
+	} else if branch == 2 || branch == 3 {
+		// move the corner along one of the lines
+		// P = far point of that line, Q = far point of the other line
+		P := linePoint1.Position
+		Q := linePoint2.Position
+		if branch == 3 {
+			P, Q = Q, P
+		}
+		direction := P.Sub(cornerPoint.Position).Normalize()
+		w := Q.Sub(P)
+		along := w.Dot(direction)
+		height := math.Abs(w.Cross(direction))
+
+		// distance of the new corner from P, the corner has to stay on the same side of P
+		distance := height/math.Tan(radians) - along
+		if distance <= 0 || math.IsNaN(distance) || math.IsInf(distance, 0) {
+			return false, nil
+		}
+		cornerPoint.Position = P.Sub(direction.Mul(distance))
+	} else if branch == 4 || branch == 5 {
+		// rotate the corner around one of the far points, keeping the length of that line
+		// A = corner, B = pivot, C = third point
+		A := cornerPoint.Position
+		B := linePoint1.Position
+		C := linePoint2.Position
+		if branch == 5 {
+			B, C = C, B
+		}
+
+		c := B.Sub(A).Magnitude()
+		a := C.Sub(B).Magnitude()
+		if a == 0 {
+			return false, nil
+		}
+
+		// law of sines gives the angle at C, then the angle at B closes the triangle
+		sinC := c * math.Sin(radians) / a
+		if sinC > 1 {
+			return false, nil
+		}
+		angleB := math.Pi - radians - math.Asin(sinC)
+		if angleB <= 0 {
+			return false, nil
+		}
+
+		// keep the corner on the side of BC it is on now
+		if C.Sub(B).Cross(A.Sub(B)) < 0 {
+			angleB = -angleB
+		}
+		cornerPoint.Position = C.Sub(B).Normalize().Rotate(angleB).Mul(c).Add(B)
+	}
+
+	return true, nil
+}
+
+func (c *SketchConstraintCornerAngle) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintCornerAngle) GetName() string {
+	return c.getName(c.Id)
+}
+
+func (c *SketchConstraintCornerAngle) GetValue() float64 {
+	return c.Angle
+}
+
+func (c *SketchConstraintCornerAngle) SetValue(value float64) {
+	c.Angle = value
+}
+
+func (c *SketchConstraintCornerAngle) GetMeasuredValue(s *Sketch) (float64, error) {
+	return c.GetCurrentAngle(s)
+}
+
+func (c *SketchConstraintCornerAngle) GetReferences() []int {
+	return []int{c.CornerPointId, c.LinePoint1Id, c.LinePoint2Id}
+}
+
+type SketchConstraintLineLength struct {
+	Id     int     `json:"id"`
+	LineId int     `json:"lineId"`
+	Length float64 `json:"length"`
+	dimension
+}
+
+func (c *SketchConstraintLineLength) Clone() SketchElement {
+	return &SketchConstraintLineLength{
+		Id:        c.Id,
+		LineId:    c.LineId,
+		Length:    c.Length,
+		dimension: c.dimension,
+	}
+}
+
+func isNearZero(v float64) bool {
+	return math.Abs(v) < 0.00001
+}
+
+func (c *SketchConstraintLineLength) IsSatisfied(s *Sketch) (bool, error) {
+	startPoint, endPoint, err := GetLinePoints(s, c.LineId)
+	if err != nil {
+		return false, err
+	}
+
+	return isNearZero(startPoint.Position.DistanceTo(endPoint.Position) - c.Length), nil
+}
+
+func (c *SketchConstraintLineLength) GetDependencies(s *Sketch) ([]int, error) {
+	line, err := GetElementByID[*SketchLine](s, c.LineId)
+	if err != nil {
+		return nil, err
+	}
+
+	return []int{line.StartId, line.EndId}, nil
+}
+
+func (c *SketchConstraintLineLength) GetResiduals(s *Sketch) ([]float64, error) {
+	startPoint, endPoint, err := GetLinePoints(s, c.LineId)
+	if err != nil {
+		return nil, err
+	}
+
+	return []float64{startPoint.Position.DistanceTo(endPoint.Position) - c.Length}, nil
+}
+
+func (c *SketchConstraintLineLength) Apply(s *Sketch, branch int) (bool, error) {
+	startPoint, endPoint, err := GetLinePoints(s, c.LineId)
+	if err != nil {
+		return false, err
+	}
+
+	currentLength := startPoint.Position.DistanceTo(endPoint.Position)
+	t := c.Length / currentLength
+
+	if branch == 0 { // Move endPoint
+		endPoint.Position = startPoint.Position.Lerp(endPoint.Position, t)
+	} else if branch == 1 { // Move startPoint
+		startPoint.Position = endPoint.Position.Lerp(startPoint.Position, t)
+	}
+
+	return true, nil
+}
+
+func (c *SketchConstraintLineLength) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintLineLength) GetName() string {
+	return c.getName(c.Id)
+}
+
+func (c *SketchConstraintLineLength) GetValue() float64 {
+	return c.Length
+}
+
+func (c *SketchConstraintLineLength) SetValue(value float64) {
+	c.Length = value
+}
+
+func (c *SketchConstraintLineLength) GetMeasuredValue(s *Sketch) (float64, error) {
+	startPoint, endPoint, err := GetLinePoints(s, c.LineId)
+	if err != nil {
+		return 0, err
+	}
+	return startPoint.Position.DistanceTo(endPoint.Position), nil
+}
+
+func (c *SketchConstraintLineLength) GetReferences() []int {
+	return []int{c.LineId}
+}
+
+func (c *SketchConstraintLineLength) GetBranches() int {
+	return 2
+}
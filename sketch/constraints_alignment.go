@@ -0,0 +1,216 @@
+package sketch
+
+// SketchConstraintCoincident keeps two points at the same location
+type SketchConstraintCoincident struct {
+	Id       int `json:"id"`
+	Point1Id int `json:"point1Id"`
+	Point2Id int `json:"point2Id"`
+}
+
+func (c *SketchConstraintCoincident) Clone() SketchElement {
+	return &SketchConstraintCoincident{
+		Id:       c.Id,
+		Point1Id: c.Point1Id,
+		Point2Id: c.Point2Id,
+	}
+}
+
+func (c *SketchConstraintCoincident) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintCoincident) GetReferences() []int {
+	return []int{c.Point1Id, c.Point2Id}
+}
+
+func (c *SketchConstraintCoincident) GetDependencies(s *Sketch) ([]int, error) {
+	return []int{c.Point1Id, c.Point2Id}, nil
+}
+
+func (c *SketchConstraintCoincident) GetResiduals(s *Sketch) ([]float64, error) {
+	points, err := GetPoints(s, c.Point1Id, c.Point2Id)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := points[1].Position.Sub(points[0].Position)
+	return []float64{offset.X, offset.Y}, nil
+}
+
+func (c *SketchConstraintCoincident) IsSatisfied(s *Sketch) (bool, error) {
+	points, err := GetPoints(s, c.Point1Id, c.Point2Id)
+	if err != nil {
+		return false, err
+	}
+
+	return isNearZero(points[0].Position.DistanceTo(points[1].Position)), nil
+}
+
+func (c *SketchConstraintCoincident) GetBranches() int {
+	return 2
+}
+
+func (c *SketchConstraintCoincident) Apply(s *Sketch, branch int) (bool, error) {
+	points, err := GetPoints(s, c.Point1Id, c.Point2Id)
+	if err != nil {
+		return false, err
+	}
+
+	if branch == 0 { // move point2 onto point1
+		points[1].Position = points[0].Position
+	} else if branch == 1 { // move point1 onto point2
+		points[0].Position = points[1].Position
+	}
+	return true, nil
+}
+
+// alignmentReferences are the two points of a horizontal or vertical constraint and the
+// line they were taken from, if any
+func alignmentReferences(point1Id, point2Id int, lineId *int) []int {
+	if lineId == nil {
+		return []int{point1Id, point2Id}
+	}
+	return []int{point1Id, point2Id, *lineId}
+}
+
+func cloneId(id *int) *int {
+	if id == nil {
+		return nil
+	}
+	value := *id
+	return &value
+}
+
+// SketchConstraintHorizontal keeps two points at the same height. When created
+// from a line it holds the line's endpoints and the line
+type SketchConstraintHorizontal struct {
+	Id       int `json:"id"`
+	Point1Id int `json:"point1Id"`
+	Point2Id int `json:"point2Id"`
+	// line the points belong to, so deleting the line deletes the constraint
+	LineId *int `json:"lineId,omitempty"`
+}
+
+func (c *SketchConstraintHorizontal) Clone() SketchElement {
+	return &SketchConstraintHorizontal{
+		Id:       c.Id,
+		Point1Id: c.Point1Id,
+		Point2Id: c.Point2Id,
+		LineId:   cloneId(c.LineId),
+	}
+}
+
+func (c *SketchConstraintHorizontal) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintHorizontal) GetReferences() []int {
+	return alignmentReferences(c.Point1Id, c.Point2Id, c.LineId)
+}
+
+func (c *SketchConstraintHorizontal) GetDependencies(s *Sketch) ([]int, error) {
+	return []int{c.Point1Id, c.Point2Id}, nil
+}
+
+func (c *SketchConstraintHorizontal) GetResiduals(s *Sketch) ([]float64, error) {
+	points, err := GetPoints(s, c.Point1Id, c.Point2Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return []float64{points[1].Position.Y - points[0].Position.Y}, nil
+}
+
+func (c *SketchConstraintHorizontal) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+
+	return isNearZero(residuals[0]), nil
+}
+
+func (c *SketchConstraintHorizontal) GetBranches() int {
+	return 2
+}
+
+func (c *SketchConstraintHorizontal) Apply(s *Sketch, branch int) (bool, error) {
+	points, err := GetPoints(s, c.Point1Id, c.Point2Id)
+	if err != nil {
+		return false, err
+	}
+
+	if branch == 0 { // move point2 level with point1
+		points[1].Position.Y = points[0].Position.Y
+	} else if branch == 1 { // move point1 level with point2
+		points[0].Position.Y = points[1].Position.Y
+	}
+	return true, nil
+}
+
+// SketchConstraintVertical keeps two points above each other. When created
+// from a line it holds the line's endpoints and the line
+type SketchConstraintVertical struct {
+	Id       int `json:"id"`
+	Point1Id int `json:"point1Id"`
+	Point2Id int `json:"point2Id"`
+	// line the points belong to, so deleting the line deletes the constraint
+	LineId *int `json:"lineId,omitempty"`
+}
+
+func (c *SketchConstraintVertical) Clone() SketchElement {
+	return &SketchConstraintVertical{
+		Id:       c.Id,
+		Point1Id: c.Point1Id,
+		Point2Id: c.Point2Id,
+		LineId:   cloneId(c.LineId),
+	}
+}
+
+func (c *SketchConstraintVertical) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintVertical) GetReferences() []int {
+	return alignmentReferences(c.Point1Id, c.Point2Id, c.LineId)
+}
+
+func (c *SketchConstraintVertical) GetDependencies(s *Sketch) ([]int, error) {
+	return []int{c.Point1Id, c.Point2Id}, nil
+}
+
+func (c *SketchConstraintVertical) GetResiduals(s *Sketch) ([]float64, error) {
+	points, err := GetPoints(s, c.Point1Id, c.Point2Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return []float64{points[1].Position.X - points[0].Position.X}, nil
+}
+
+func (c *SketchConstraintVertical) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+
+	return isNearZero(residuals[0]), nil
+}
+
+func (c *SketchConstraintVertical) GetBranches() int {
+	return 2
+}
+
+func (c *SketchConstraintVertical) Apply(s *Sketch, branch int) (bool, error) {
+	points, err := GetPoints(s, c.Point1Id, c.Point2Id)
+	if err != nil {
+		return false, err
+	}
+
+	if branch == 0 { // move point2 in line with point1
+		points[1].Position.X = points[0].Position.X
+	} else if branch == 1 { // move point1 in line with point2
+		points[0].Position.X = points[1].Position.X
+	}
+	return true, nil
+}
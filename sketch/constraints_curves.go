@@ -0,0 +1,587 @@
+package sketch
+
+import (
+	"fmt"
+	"math"
+
+	"unholy-cad/geom"
+)
+
+// SketchConstraintRadius sets the radius of a circle or an arc
+type SketchConstraintRadius struct {
+	Id      int     `json:"id"`
+	CurveId int     `json:"curveId"`
+	Radius  float64 `json:"radius"`
+	dimension
+}
+
+func (c *SketchConstraintRadius) Clone() SketchElement {
+	return &SketchConstraintRadius{
+		Id:        c.Id,
+		CurveId:   c.CurveId,
+		Radius:    c.Radius,
+		dimension: c.dimension,
+	}
+}
+
+func (c *SketchConstraintRadius) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintRadius) GetName() string {
+	return c.getName(c.Id)
+}
+
+func (c *SketchConstraintRadius) GetValue() float64 {
+	return c.Radius
+}
+
+func (c *SketchConstraintRadius) SetValue(value float64) {
+	c.Radius = value
+}
+
+func (c *SketchConstraintRadius) GetMeasuredValue(s *Sketch) (float64, error) {
+	curve, err := GetCurve(s, c.CurveId)
+	if err != nil {
+		return 0, err
+	}
+	return curve.GetRadius(s)
+}
+
+func (c *SketchConstraintRadius) GetReferences() []int {
+	return []int{c.CurveId}
+}
+
+func (c *SketchConstraintRadius) GetDependencies(s *Sketch) ([]int, error) {
+	return getCurveDependencies(s, c.CurveId)
+}
+
+func (c *SketchConstraintRadius) GetResiduals(s *Sketch) ([]float64, error) {
+	curve, err := GetCurve(s, c.CurveId)
+	if err != nil {
+		return nil, err
+	}
+	radius, err := curve.GetRadius(s)
+	if err != nil {
+		return nil, err
+	}
+	return []float64{radius - c.Radius}, nil
+}
+
+func (c *SketchConstraintRadius) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(residuals[0]), nil
+}
+
+func (c *SketchConstraintRadius) GetBranches() int {
+	return 1
+}
+
+func (c *SketchConstraintRadius) Apply(s *Sketch, branch int) (bool, error) {
+	curve, err := GetCurve(s, c.CurveId)
+	if err != nil {
+		return false, err
+	}
+	return true, setCurveRadius(s, curve, c.Radius)
+}
+
+// SketchConstraintDiameter sets the diameter of a circle or an arc
+type SketchConstraintDiameter struct {
+	Id       int     `json:"id"`
+	CurveId  int     `json:"curveId"`
+	Diameter float64 `json:"diameter"`
+	dimension
+}
+
+func (c *SketchConstraintDiameter) Clone() SketchElement {
+	return &SketchConstraintDiameter{
+		Id:        c.Id,
+		CurveId:   c.CurveId,
+		Diameter:  c.Diameter,
+		dimension: c.dimension,
+	}
+}
+
+func (c *SketchConstraintDiameter) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintDiameter) GetName() string {
+	return c.getName(c.Id)
+}
+
+func (c *SketchConstraintDiameter) GetValue() float64 {
+	return c.Diameter
+}
+
+func (c *SketchConstraintDiameter) SetValue(value float64) {
+	c.Diameter = value
+}
+
+func (c *SketchConstraintDiameter) GetMeasuredValue(s *Sketch) (float64, error) {
+	curve, err := GetCurve(s, c.CurveId)
+	if err != nil {
+		return 0, err
+	}
+	radius, err := curve.GetRadius(s)
+	return 2 * radius, err
+}
+
+func (c *SketchConstraintDiameter) GetReferences() []int {
+	return []int{c.CurveId}
+}
+
+func (c *SketchConstraintDiameter) GetDependencies(s *Sketch) ([]int, error) {
+	return getCurveDependencies(s, c.CurveId)
+}
+
+func (c *SketchConstraintDiameter) GetResiduals(s *Sketch) ([]float64, error) {
+	curve, err := GetCurve(s, c.CurveId)
+	if err != nil {
+		return nil, err
+	}
+	radius, err := curve.GetRadius(s)
+	if err != nil {
+		return nil, err
+	}
+	return []float64{2*radius - c.Diameter}, nil
+}
+
+func (c *SketchConstraintDiameter) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(residuals[0]), nil
+}
+
+func (c *SketchConstraintDiameter) GetBranches() int {
+	return 1
+}
+
+func (c *SketchConstraintDiameter) Apply(s *Sketch, branch int) (bool, error) {
+	curve, err := GetCurve(s, c.CurveId)
+	if err != nil {
+		return false, err
+	}
+	return true, setCurveRadius(s, curve, c.Diameter/2)
+}
+
+// SketchConstraintTangent makes a line touch a circle or an arc. The line is treated as
+// infinite, so the touching point may lie outside its endpoints
+type SketchConstraintTangent struct {
+	Id      int `json:"id"`
+	LineId  int `json:"lineId"`
+	CurveId int `json:"curveId"`
+}
+
+func (c *SketchConstraintTangent) Clone() SketchElement {
+	return &SketchConstraintTangent{
+		Id:      c.Id,
+		LineId:  c.LineId,
+		CurveId: c.CurveId,
+	}
+}
+
+func (c *SketchConstraintTangent) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintTangent) GetReferences() []int {
+	return []int{c.LineId, c.CurveId}
+}
+
+func (c *SketchConstraintTangent) GetDependencies(s *Sketch) ([]int, error) {
+	line, err := GetElementByID[*SketchLine](s, c.LineId)
+	if err != nil {
+		return nil, err
+	}
+	dependencies, err := getCurveShapeDependencies(s, c.CurveId)
+	if err != nil {
+		return nil, err
+	}
+	return append(dependencies, line.StartId, line.EndId), nil
+}
+
+// getCenterDistance returns the signed distance of the curve center from the line and the radius
+func (c *SketchConstraintTangent) getCenterDistance(s *Sketch) (float64, float64, error) {
+	startPoint, endPoint, err := GetLinePoints(s, c.LineId)
+	if err != nil {
+		return 0, 0, err
+	}
+	curve, err := GetCurve(s, c.CurveId)
+	if err != nil {
+		return 0, 0, err
+	}
+	center, err := curve.GetCenter(s)
+	if err != nil {
+		return 0, 0, err
+	}
+	radius, err := curve.GetRadius(s)
+	if err != nil {
+		return 0, 0, err
+	}
+	return signedDistanceToLine(center.Position, startPoint.Position, endPoint.Position), radius, nil
+}
+
+// GetTouchPoint returns the foot of the curve center on the line
+func (c *SketchConstraintTangent) GetTouchPoint(s *Sketch) (geom.Vec2, error) {
+	startPoint, endPoint, err := GetLinePoints(s, c.LineId)
+	if err != nil {
+		return geom.Vec2{}, err
+	}
+	curve, err := GetCurve(s, c.CurveId)
+	if err != nil {
+		return geom.Vec2{}, err
+	}
+	center, err := curve.GetCenter(s)
+	if err != nil {
+		return geom.Vec2{}, err
+	}
+	return projectOntoLine(center.Position, startPoint.Position, endPoint.Position), nil
+}
+
+func (c *SketchConstraintTangent) GetResiduals(s *Sketch) ([]float64, error) {
+	distance, radius, err := c.getCenterDistance(s)
+	if err != nil {
+		return nil, err
+	}
+	return []float64{math.Abs(distance) - radius}, nil
+}
+
+func (c *SketchConstraintTangent) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(residuals[0]), nil
+}
+
+func (c *SketchConstraintTangent) GetBranches() int {
+	return 2
+}
+
+func (c *SketchConstraintTangent) Apply(s *Sketch, branch int) (bool, error) {
+	distance, radius, err := c.getCenterDistance(s)
+	if err != nil {
+		return false, err
+	}
+	startPoint, endPoint, err := GetLinePoints(s, c.LineId)
+	if err != nil {
+		return false, err
+	}
+	// unit normal of the line pointing towards the center
+	normal := endPoint.Position.Sub(startPoint.Position).Normalize().Tangent()
+	if distance < 0 {
+		normal = normal.Mul(-1)
+	}
+	offset := normal.Mul(radius - math.Abs(distance))
+
+	if branch == 0 { // move the curve away from or towards the line
+		curve, err := GetCurve(s, c.CurveId)
+		if err != nil {
+			return false, err
+		}
+		return true, translateCurve(s, curve, offset)
+	} else if branch == 1 { // move the line
+		startPoint.Position = startPoint.Position.Sub(offset)
+		endPoint.Position = endPoint.Position.Sub(offset)
+	}
+	return true, nil
+}
+
+// SketchConstraintArcTangent joins two arcs that share an endpoint without a kink (G1),
+// so the path continues from one arc into the other in the same direction
+type SketchConstraintArcTangent struct {
+	Id     int `json:"id"`
+	Arc1Id int `json:"arc1Id"`
+	Arc2Id int `json:"arc2Id"`
+}
+
+func (c *SketchConstraintArcTangent) Clone() SketchElement {
+	return &SketchConstraintArcTangent{
+		Id:     c.Id,
+		Arc1Id: c.Arc1Id,
+		Arc2Id: c.Arc2Id,
+	}
+}
+
+func (c *SketchConstraintArcTangent) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintArcTangent) GetReferences() []int {
+	return []int{c.Arc1Id, c.Arc2Id}
+}
+
+// GetArcs resolves both arcs and the id of the endpoint they share
+func (c *SketchConstraintArcTangent) GetArcs(s *Sketch) (*SketchArc, *SketchArc, int, error) {
+	arc1, err := GetElementByID[*SketchArc](s, c.Arc1Id)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	arc2, err := GetElementByID[*SketchArc](s, c.Arc2Id)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	for _, id := range []int{arc1.StartId, arc1.EndId} {
+		if id == arc2.StartId || id == arc2.EndId {
+			return arc1, arc2, id, nil
+		}
+	}
+	return nil, nil, 0, &ElementError{Id: c.Arc2Id, Err: fmt.Errorf("%w with arc %d", ErrNoSharedPoint, c.Arc1Id)}
+}
+
+func (c *SketchConstraintArcTangent) GetDependencies(s *Sketch) ([]int, error) {
+	arc1, arc2, _, err := c.GetArcs(s)
+	if err != nil {
+		return nil, err
+	}
+	return append(arc1.GetReferences(), arc2.GetReferences()...), nil
+}
+
+// GetResiduals returns the angle between the direction into arc1 and the reversed direction
+// into arc2 at the shared point, zero only for a smooth joint and not for a cusp
+func (c *SketchConstraintArcTangent) GetResiduals(s *Sketch) ([]float64, error) {
+	arc1, arc2, sharedId, err := c.GetArcs(s)
+	if err != nil {
+		return nil, err
+	}
+	t1, err := arc1.GetDirectionAt(s, sharedId)
+	if err != nil {
+		return nil, err
+	}
+	t2, err := arc2.GetDirectionAt(s, sharedId)
+	if err != nil {
+		return nil, err
+	}
+	t2 = t2.Mul(-1)
+	return []float64{math.Atan2(t1.Cross(t2), t1.Dot(t2))}, nil
+}
+
+func (c *SketchConstraintArcTangent) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(residuals[0]), nil
+}
+
+func (c *SketchConstraintArcTangent) GetBranches() int {
+	return 2
+}
+
+// Apply rotates one of the arcs around the shared point
+func (c *SketchConstraintArcTangent) Apply(s *Sketch, branch int) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	arc1, arc2, sharedId, err := c.GetArcs(s)
+	if err != nil {
+		return false, err
+	}
+	shared, err := GetElementByID[*SketchPoint](s, sharedId)
+	if err != nil {
+		return false, err
+	}
+
+	arc, angle := arc2, -residuals[0]
+	if branch == 1 {
+		arc, angle = arc1, residuals[0]
+	}
+	points, err := GetPoints(s, arc.CenterId, arc.StartId, arc.EndId)
+	if err != nil {
+		return false, err
+	}
+	for _, point := range points {
+		if point.Id != sharedId {
+			point.Position = point.Position.RotateAround(shared.Position, angle)
+		}
+	}
+	return true, nil
+}
+
+// SketchConstraintConcentric gives two circles or arcs the same center
+type SketchConstraintConcentric struct {
+	Id       int `json:"id"`
+	Curve1Id int `json:"curve1Id"`
+	Curve2Id int `json:"curve2Id"`
+}
+
+func (c *SketchConstraintConcentric) Clone() SketchElement {
+	return &SketchConstraintConcentric{
+		Id:       c.Id,
+		Curve1Id: c.Curve1Id,
+		Curve2Id: c.Curve2Id,
+	}
+}
+
+func (c *SketchConstraintConcentric) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintConcentric) GetReferences() []int {
+	return []int{c.Curve1Id, c.Curve2Id}
+}
+
+// GetCenters resolves the center points of both curves
+func (c *SketchConstraintConcentric) GetCenters(s *Sketch) (*SketchPoint, *SketchPoint, error) {
+	curve1, err := GetCurve(s, c.Curve1Id)
+	if err != nil {
+		return nil, nil, err
+	}
+	curve2, err := GetCurve(s, c.Curve2Id)
+	if err != nil {
+		return nil, nil, err
+	}
+	center1, err := curve1.GetCenter(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	center2, err := curve2.GetCenter(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	return center1, center2, nil
+}
+
+func (c *SketchConstraintConcentric) GetDependencies(s *Sketch) ([]int, error) {
+	center1, center2, err := c.GetCenters(s)
+	if err != nil {
+		return nil, err
+	}
+	return []int{center1.Id, center2.Id}, nil
+}
+
+func (c *SketchConstraintConcentric) GetResiduals(s *Sketch) ([]float64, error) {
+	center1, center2, err := c.GetCenters(s)
+	if err != nil {
+		return nil, err
+	}
+	offset := center2.Position.Sub(center1.Position)
+	return []float64{offset.X, offset.Y}, nil
+}
+
+func (c *SketchConstraintConcentric) IsSatisfied(s *Sketch) (bool, error) {
+	center1, center2, err := c.GetCenters(s)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(center1.Position.DistanceTo(center2.Position)), nil
+}
+
+func (c *SketchConstraintConcentric) GetBranches() int {
+	return 2
+}
+
+// Apply moves one of the curves as a whole onto the center of the other
+func (c *SketchConstraintConcentric) Apply(s *Sketch, branch int) (bool, error) {
+	center1, center2, err := c.GetCenters(s)
+	if err != nil {
+		return false, err
+	}
+
+	curveId, offset := c.Curve2Id, center1.Position.Sub(center2.Position)
+	if branch == 1 {
+		curveId, offset = c.Curve1Id, center2.Position.Sub(center1.Position)
+	}
+	curve, err := GetCurve(s, curveId)
+	if err != nil {
+		return false, err
+	}
+	return true, translateCurve(s, curve, offset)
+}
+
+// SketchConstraintPointOnCurve keeps a point on the outline of a circle, or on the full
+// circle an arc is part of
+type SketchConstraintPointOnCurve struct {
+	Id      int `json:"id"`
+	PointId int `json:"pointId"`
+	CurveId int `json:"curveId"`
+}
+
+func (c *SketchConstraintPointOnCurve) Clone() SketchElement {
+	return &SketchConstraintPointOnCurve{
+		Id:      c.Id,
+		PointId: c.PointId,
+		CurveId: c.CurveId,
+	}
+}
+
+func (c *SketchConstraintPointOnCurve) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintPointOnCurve) GetReferences() []int {
+	return []int{c.PointId, c.CurveId}
+}
+
+func (c *SketchConstraintPointOnCurve) GetDependencies(s *Sketch) ([]int, error) {
+	dependencies, err := getCurveShapeDependencies(s, c.CurveId)
+	if err != nil {
+		return nil, err
+	}
+	return append(dependencies, c.PointId), nil
+}
+
+func (c *SketchConstraintPointOnCurve) GetResiduals(s *Sketch) ([]float64, error) {
+	point, err := GetElementByID[*SketchPoint](s, c.PointId)
+	if err != nil {
+		return nil, err
+	}
+	curve, err := GetCurve(s, c.CurveId)
+	if err != nil {
+		return nil, err
+	}
+	center, err := curve.GetCenter(s)
+	if err != nil {
+		return nil, err
+	}
+	radius, err := curve.GetRadius(s)
+	if err != nil {
+		return nil, err
+	}
+	return []float64{center.Position.DistanceTo(point.Position) - radius}, nil
+}
+
+func (c *SketchConstraintPointOnCurve) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(residuals[0]), nil
+}
+
+func (c *SketchConstraintPointOnCurve) GetBranches() int {
+	return 1
+}
+
+// Apply moves the point along its ray from the center onto the curve
+func (c *SketchConstraintPointOnCurve) Apply(s *Sketch, branch int) (bool, error) {
+	point, err := GetElementByID[*SketchPoint](s, c.PointId)
+	if err != nil {
+		return false, err
+	}
+	curve, err := GetCurve(s, c.CurveId)
+	if err != nil {
+		return false, err
+	}
+	center, err := curve.GetCenter(s)
+	if err != nil {
+		return false, err
+	}
+	radius, err := curve.GetRadius(s)
+	if err != nil {
+		return false, err
+	}
+	direction := point.Position.Sub(center.Position)
+	if direction.Magnitude() == 0 {
+		return false, nil
+	}
+	point.Position = center.Position.Add(direction.Normalize().Mul(radius))
+	return true, nil
+}
@@ -0,0 +1,249 @@
+package sketch
+
+import (
+	"math"
+
+	"unholy-cad/geom"
+)
+
+// DistanceDirection selects which component of the offset between two points is measured
+type DistanceDirection string
+
+const (
+	DistanceAligned    DistanceDirection = "aligned"
+	DistanceHorizontal DistanceDirection = "horizontal"
+	DistanceVertical   DistanceDirection = "vertical"
+)
+
+// SketchConstraintPointDistance sets the distance between two points, either straight
+// between them or only along the x or y axis
+type SketchConstraintPointDistance struct {
+	Id        int               `json:"id"`
+	Point1Id  int               `json:"point1Id"`
+	Point2Id  int               `json:"point2Id"`
+	Direction DistanceDirection `json:"direction"`
+	Distance  float64           `json:"distance"`
+	dimension
+}
+
+func (c *SketchConstraintPointDistance) Clone() SketchElement {
+	return &SketchConstraintPointDistance{
+		Id:        c.Id,
+		Point1Id:  c.Point1Id,
+		Point2Id:  c.Point2Id,
+		Direction: c.Direction,
+		Distance:  c.Distance,
+		dimension: c.dimension,
+	}
+}
+
+func (c *SketchConstraintPointDistance) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintPointDistance) GetName() string {
+	return c.getName(c.Id)
+}
+
+func (c *SketchConstraintPointDistance) GetValue() float64 {
+	return c.Distance
+}
+
+func (c *SketchConstraintPointDistance) SetValue(value float64) {
+	c.Distance = value
+}
+
+func (c *SketchConstraintPointDistance) GetMeasuredValue(s *Sketch) (float64, error) {
+	return c.GetCurrentDistance(s)
+}
+
+func (c *SketchConstraintPointDistance) GetReferences() []int {
+	return []int{c.Point1Id, c.Point2Id}
+}
+
+func (c *SketchConstraintPointDistance) GetDependencies(s *Sketch) ([]int, error) {
+	return []int{c.Point1Id, c.Point2Id}, nil
+}
+
+// GetCurrentDistance returns the measured distance in the direction of the dimension
+func (c *SketchConstraintPointDistance) GetCurrentDistance(s *Sketch) (float64, error) {
+	points, err := GetPoints(s, c.Point1Id, c.Point2Id)
+	if err != nil {
+		return 0, err
+	}
+	offset := points[1].Position.Sub(points[0].Position)
+	switch c.Direction {
+	case DistanceHorizontal:
+		return math.Abs(offset.X), nil
+	case DistanceVertical:
+		return math.Abs(offset.Y), nil
+	}
+	return offset.Magnitude(), nil
+}
+
+func (c *SketchConstraintPointDistance) GetResiduals(s *Sketch) ([]float64, error) {
+	distance, err := c.GetCurrentDistance(s)
+	if err != nil {
+		return nil, err
+	}
+	return []float64{distance - c.Distance}, nil
+}
+
+func (c *SketchConstraintPointDistance) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(residuals[0]), nil
+}
+
+func (c *SketchConstraintPointDistance) GetBranches() int {
+	return 2
+}
+
+func (c *SketchConstraintPointDistance) Apply(s *Sketch, branch int) (bool, error) {
+	points, err := GetPoints(s, c.Point1Id, c.Point2Id)
+	if err != nil {
+		return false, err
+	}
+	// the moved point and the point it keeps its distance from
+	moved, anchor := points[1], points[0]
+	if branch == 1 {
+		moved, anchor = points[0], points[1]
+	}
+
+	offset := moved.Position.Sub(anchor.Position)
+	switch c.Direction {
+	case DistanceHorizontal:
+		moved.Position.X = anchor.Position.X + math.Copysign(c.Distance, offset.X)
+	case DistanceVertical:
+		moved.Position.Y = anchor.Position.Y + math.Copysign(c.Distance, offset.Y)
+	default:
+		if offset.Magnitude() == 0 {
+			offset = geom.Vec2{X: 1}
+		}
+		moved.Position = anchor.Position.Add(offset.Normalize().Mul(c.Distance))
+	}
+	return true, nil
+}
+
+// SketchConstraintPointLineDistance sets the perpendicular distance from a point to the
+// infinite line through a line's endpoints
+type SketchConstraintPointLineDistance struct {
+	Id       int     `json:"id"`
+	PointId  int     `json:"pointId"`
+	LineId   int     `json:"lineId"`
+	Distance float64 `json:"distance"`
+	dimension
+}
+
+func (c *SketchConstraintPointLineDistance) Clone() SketchElement {
+	return &SketchConstraintPointLineDistance{
+		Id:        c.Id,
+		PointId:   c.PointId,
+		LineId:    c.LineId,
+		Distance:  c.Distance,
+		dimension: c.dimension,
+	}
+}
+
+func (c *SketchConstraintPointLineDistance) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintPointLineDistance) GetName() string {
+	return c.getName(c.Id)
+}
+
+func (c *SketchConstraintPointLineDistance) GetValue() float64 {
+	return c.Distance
+}
+
+func (c *SketchConstraintPointLineDistance) SetValue(value float64) {
+	c.Distance = value
+}
+
+func (c *SketchConstraintPointLineDistance) GetMeasuredValue(s *Sketch) (float64, error) {
+	point, foot, err := c.GetFoot(s)
+	if err != nil {
+		return 0, err
+	}
+	return point.DistanceTo(foot), nil
+}
+
+func (c *SketchConstraintPointLineDistance) GetReferences() []int {
+	return []int{c.PointId, c.LineId}
+}
+
+func (c *SketchConstraintPointLineDistance) GetDependencies(s *Sketch) ([]int, error) {
+	line, err := GetElementByID[*SketchLine](s, c.LineId)
+	if err != nil {
+		return nil, err
+	}
+	return []int{c.PointId, line.StartId, line.EndId}, nil
+}
+
+// GetFoot returns the point and its perpendicular foot on the line
+func (c *SketchConstraintPointLineDistance) GetFoot(s *Sketch) (geom.Vec2, geom.Vec2, error) {
+	point, err := GetElementByID[*SketchPoint](s, c.PointId)
+	if err != nil {
+		return geom.Vec2{}, geom.Vec2{}, err
+	}
+	startPoint, endPoint, err := GetLinePoints(s, c.LineId)
+	if err != nil {
+		return geom.Vec2{}, geom.Vec2{}, err
+	}
+	return point.Position, projectOntoLine(point.Position, startPoint.Position, endPoint.Position), nil
+}
+
+func (c *SketchConstraintPointLineDistance) GetResiduals(s *Sketch) ([]float64, error) {
+	point, err := GetElementByID[*SketchPoint](s, c.PointId)
+	if err != nil {
+		return nil, err
+	}
+	startPoint, endPoint, err := GetLinePoints(s, c.LineId)
+	if err != nil {
+		return nil, err
+	}
+	distance := signedDistanceToLine(point.Position, startPoint.Position, endPoint.Position)
+	return []float64{math.Abs(distance) - c.Distance}, nil
+}
+
+func (c *SketchConstraintPointLineDistance) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(residuals[0]), nil
+}
+
+func (c *SketchConstraintPointLineDistance) GetBranches() int {
+	return 2
+}
+
+func (c *SketchConstraintPointLineDistance) Apply(s *Sketch, branch int) (bool, error) {
+	point, err := GetElementByID[*SketchPoint](s, c.PointId)
+	if err != nil {
+		return false, err
+	}
+	startPoint, endPoint, err := GetLinePoints(s, c.LineId)
+	if err != nil {
+		return false, err
+	}
+
+	// unit normal of the line pointing towards the point
+	distance := signedDistanceToLine(point.Position, startPoint.Position, endPoint.Position)
+	normal := endPoint.Position.Sub(startPoint.Position).Normalize().Tangent()
+	if distance < 0 {
+		normal = normal.Mul(-1)
+	}
+	offset := normal.Mul(c.Distance - math.Abs(distance))
+
+	if branch == 0 { // move the point
+		point.Position = point.Position.Add(offset)
+	} else if branch == 1 { // move the line
+		startPoint.Position = startPoint.Position.Sub(offset)
+		endPoint.Position = endPoint.Position.Sub(offset)
+	}
+	return true, nil
+}
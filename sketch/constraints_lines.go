@@ -0,0 +1,390 @@
+package sketch
+
+import (
+	"math"
+
+	"unholy-cad/geom"
+)
+
+// getLineDirection returns the vector from the start to the end of a line
+func getLineDirection(s *Sketch, lineId int) (geom.Vec2, error) {
+	startPoint, endPoint, err := GetLinePoints(s, lineId)
+	if err != nil {
+		return geom.Vec2{}, err
+	}
+	return endPoint.Position.Sub(startPoint.Position), nil
+}
+
+// getLinesAngle returns the signed angle in radians from the direction of line1 to the
+// direction of line2, counter clockwise positive, between -π and π
+func getLinesAngle(s *Sketch, line1Id, line2Id int) (float64, error) {
+	d1, err := getLineDirection(s, line1Id)
+	if err != nil {
+		return 0, err
+	}
+	d2, err := getLineDirection(s, line2Id)
+	if err != nil {
+		return 0, err
+	}
+	return math.Atan2(d1.Cross(d2), d1.Dot(d2)), nil
+}
+
+// getLinesDependencies returns the endpoints of both lines
+func getLinesDependencies(s *Sketch, line1Id, line2Id int) ([]int, error) {
+	line1, err := GetElementByID[*SketchLine](s, line1Id)
+	if err != nil {
+		return nil, err
+	}
+	line2, err := GetElementByID[*SketchLine](s, line2Id)
+	if err != nil {
+		return nil, err
+	}
+	return []int{line1.StartId, line1.EndId, line2.StartId, line2.EndId}, nil
+}
+
+// rotateLine rotates the end point of a line around its start point
+func rotateLine(s *Sketch, lineId int, angle float64) error {
+	startPoint, endPoint, err := GetLinePoints(s, lineId)
+	if err != nil {
+		return err
+	}
+	endPoint.Position = endPoint.Position.RotateAround(startPoint.Position, angle)
+	return nil
+}
+
+// wrapAngle maps an angle in radians to the range -π to π
+func wrapAngle(angle float64) float64 {
+	angle = math.Mod(angle+math.Pi, 2*math.Pi)
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+	return angle - math.Pi
+}
+
+// SketchConstraintParallel keeps two lines parallel, they may point in opposite directions
+type SketchConstraintParallel struct {
+	Id      int `json:"id"`
+	Line1Id int `json:"line1Id"`
+	Line2Id int `json:"line2Id"`
+}
+
+func (c *SketchConstraintParallel) Clone() SketchElement {
+	return &SketchConstraintParallel{
+		Id:      c.Id,
+		Line1Id: c.Line1Id,
+		Line2Id: c.Line2Id,
+	}
+}
+
+func (c *SketchConstraintParallel) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintParallel) GetReferences() []int {
+	return []int{c.Line1Id, c.Line2Id}
+}
+
+func (c *SketchConstraintParallel) GetDependencies(s *Sketch) ([]int, error) {
+	return getLinesDependencies(s, c.Line1Id, c.Line2Id)
+}
+
+func (c *SketchConstraintParallel) GetResiduals(s *Sketch) ([]float64, error) {
+	angle, err := getLinesAngle(s, c.Line1Id, c.Line2Id)
+	if err != nil {
+		return nil, err
+	}
+	// zero for both 0° and 180°
+	return []float64{math.Sin(angle)}, nil
+}
+
+func (c *SketchConstraintParallel) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(residuals[0]), nil
+}
+
+func (c *SketchConstraintParallel) GetBranches() int {
+	return 2
+}
+
+func (c *SketchConstraintParallel) Apply(s *Sketch, branch int) (bool, error) {
+	angle, err := getLinesAngle(s, c.Line1Id, c.Line2Id)
+	if err != nil {
+		return false, err
+	}
+	// rotate towards whichever of 0° and 180° is closer
+	offset := angle
+	if math.Abs(angle) > math.Pi/2 {
+		offset = wrapAngle(angle - math.Pi)
+	}
+
+	if branch == 0 { // rotate line2
+		err = rotateLine(s, c.Line2Id, -offset)
+	} else if branch == 1 { // rotate line1
+		err = rotateLine(s, c.Line1Id, offset)
+	}
+	return err == nil, err
+}
+
+// SketchConstraintPerpendicular keeps two lines at a right angle
+type SketchConstraintPerpendicular struct {
+	Id      int `json:"id"`
+	Line1Id int `json:"line1Id"`
+	Line2Id int `json:"line2Id"`
+}
+
+func (c *SketchConstraintPerpendicular) Clone() SketchElement {
+	return &SketchConstraintPerpendicular{
+		Id:      c.Id,
+		Line1Id: c.Line1Id,
+		Line2Id: c.Line2Id,
+	}
+}
+
+func (c *SketchConstraintPerpendicular) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintPerpendicular) GetReferences() []int {
+	return []int{c.Line1Id, c.Line2Id}
+}
+
+func (c *SketchConstraintPerpendicular) GetDependencies(s *Sketch) ([]int, error) {
+	return getLinesDependencies(s, c.Line1Id, c.Line2Id)
+}
+
+func (c *SketchConstraintPerpendicular) GetResiduals(s *Sketch) ([]float64, error) {
+	angle, err := getLinesAngle(s, c.Line1Id, c.Line2Id)
+	if err != nil {
+		return nil, err
+	}
+	// zero for both 90° and -90°
+	return []float64{math.Cos(angle)}, nil
+}
+
+func (c *SketchConstraintPerpendicular) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(residuals[0]), nil
+}
+
+func (c *SketchConstraintPerpendicular) GetBranches() int {
+	return 2
+}
+
+func (c *SketchConstraintPerpendicular) Apply(s *Sketch, branch int) (bool, error) {
+	angle, err := getLinesAngle(s, c.Line1Id, c.Line2Id)
+	if err != nil {
+		return false, err
+	}
+	// rotate towards whichever of 90° and -90° is closer
+	offset := angle - math.Pi/2
+	if angle < 0 {
+		offset = angle + math.Pi/2
+	}
+
+	if branch == 0 { // rotate line2
+		err = rotateLine(s, c.Line2Id, -offset)
+	} else if branch == 1 { // rotate line1
+		err = rotateLine(s, c.Line1Id, offset)
+	}
+	return err == nil, err
+}
+
+// SketchConstraintLineAngle sets the angle between the directions of two lines that
+// don't need to share a point. The angle is in degrees, measured counter clockwise from
+// line1 to line2, so 90 and 270 are different readings
+type SketchConstraintLineAngle struct {
+	Id      int     `json:"id"`
+	Line1Id int     `json:"line1Id"`
+	Line2Id int     `json:"line2Id"`
+	Angle   float64 `json:"angle"`
+	dimension
+}
+
+func (c *SketchConstraintLineAngle) Clone() SketchElement {
+	return &SketchConstraintLineAngle{
+		Id:        c.Id,
+		Line1Id:   c.Line1Id,
+		Line2Id:   c.Line2Id,
+		Angle:     c.Angle,
+		dimension: c.dimension,
+	}
+}
+
+func (c *SketchConstraintLineAngle) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintLineAngle) GetName() string {
+	return c.getName(c.Id)
+}
+
+func (c *SketchConstraintLineAngle) GetValue() float64 {
+	return c.Angle
+}
+
+func (c *SketchConstraintLineAngle) SetValue(value float64) {
+	c.Angle = value
+}
+
+func (c *SketchConstraintLineAngle) GetMeasuredValue(s *Sketch) (float64, error) {
+	return c.GetCurrentAngle(s)
+}
+
+func (c *SketchConstraintLineAngle) GetReferences() []int {
+	return []int{c.Line1Id, c.Line2Id}
+}
+
+// GetCurrentAngle returns the counter clockwise angle from line1 to line2 in degrees, between 0 and 360
+func (c *SketchConstraintLineAngle) GetCurrentAngle(s *Sketch) (float64, error) {
+	angle, err := getLinesAngle(s, c.Line1Id, c.Line2Id)
+	if err != nil {
+		return 0, err
+	}
+	degrees := angle * 180 / math.Pi
+	if degrees < 0 {
+		degrees += 360
+	}
+	return degrees, nil
+}
+
+func (c *SketchConstraintLineAngle) GetDependencies(s *Sketch) ([]int, error) {
+	return getLinesDependencies(s, c.Line1Id, c.Line2Id)
+}
+
+func (c *SketchConstraintLineAngle) GetResiduals(s *Sketch) ([]float64, error) {
+	angle, err := getLinesAngle(s, c.Line1Id, c.Line2Id)
+	if err != nil {
+		return nil, err
+	}
+	// wrapped so 350° and -10° are the same reading and the residual has no jump near the target
+	return []float64{wrapAngle(angle - c.Angle*math.Pi/180)}, nil
+}
+
+func (c *SketchConstraintLineAngle) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(residuals[0]), nil
+}
+
+func (c *SketchConstraintLineAngle) GetBranches() int {
+	return 2
+}
+
+func (c *SketchConstraintLineAngle) Apply(s *Sketch, branch int) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	offset := residuals[0]
+
+	if branch == 0 { // rotate line2
+		err = rotateLine(s, c.Line2Id, -offset)
+	} else if branch == 1 { // rotate line1
+		err = rotateLine(s, c.Line1Id, offset)
+	}
+	return err == nil, err
+}
+
+// signedDistanceToLine returns the distance of a point from the infinite line through a and b,
+// positive on the left of the direction from a to b
+func signedDistanceToLine(p, a, b geom.Vec2) float64 {
+	direction := b.Sub(a)
+	length := direction.Magnitude()
+	if length == 0 {
+		return p.DistanceTo(a)
+	}
+	return direction.Cross(p.Sub(a)) / length
+}
+
+// projectOntoLine returns the closest point to p on the infinite line through a and b
+func projectOntoLine(p, a, b geom.Vec2) geom.Vec2 {
+	direction := b.Sub(a)
+	lengthSquared := direction.Dot(direction)
+	if lengthSquared == 0 {
+		return a
+	}
+	return a.Add(direction.Mul(p.Sub(a).Dot(direction) / lengthSquared))
+}
+
+// SketchConstraintPointOnLine keeps a point on the infinite line through a line's endpoints
+type SketchConstraintPointOnLine struct {
+	Id      int `json:"id"`
+	PointId int `json:"pointId"`
+	LineId  int `json:"lineId"`
+}
+
+func (c *SketchConstraintPointOnLine) Clone() SketchElement {
+	return &SketchConstraintPointOnLine{
+		Id:      c.Id,
+		PointId: c.PointId,
+		LineId:  c.LineId,
+	}
+}
+
+func (c *SketchConstraintPointOnLine) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintPointOnLine) GetReferences() []int {
+	return []int{c.PointId, c.LineId}
+}
+
+func (c *SketchConstraintPointOnLine) GetDependencies(s *Sketch) ([]int, error) {
+	line, err := GetElementByID[*SketchLine](s, c.LineId)
+	if err != nil {
+		return nil, err
+	}
+	return []int{c.PointId, line.StartId, line.EndId}, nil
+}
+
+func (c *SketchConstraintPointOnLine) GetResiduals(s *Sketch) ([]float64, error) {
+	point, err := GetElementByID[*SketchPoint](s, c.PointId)
+	if err != nil {
+		return nil, err
+	}
+	startPoint, endPoint, err := GetLinePoints(s, c.LineId)
+	if err != nil {
+		return nil, err
+	}
+	return []float64{signedDistanceToLine(point.Position, startPoint.Position, endPoint.Position)}, nil
+}
+
+func (c *SketchConstraintPointOnLine) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(residuals[0]), nil
+}
+
+func (c *SketchConstraintPointOnLine) GetBranches() int {
+	return 2
+}
+
+func (c *SketchConstraintPointOnLine) Apply(s *Sketch, branch int) (bool, error) {
+	point, err := GetElementByID[*SketchPoint](s, c.PointId)
+	if err != nil {
+		return false, err
+	}
+	startPoint, endPoint, err := GetLinePoints(s, c.LineId)
+	if err != nil {
+		return false, err
+	}
+
+	offset := projectOntoLine(point.Position, startPoint.Position, endPoint.Position).Sub(point.Position)
+	if branch == 0 { // move the point onto the line
+		point.Position = point.Position.Add(offset)
+	} else if branch == 1 { // move the line through the point
+		startPoint.Position = startPoint.Position.Sub(offset)
+		endPoint.Position = endPoint.Position.Sub(offset)
+	}
+	return true, nil
+}
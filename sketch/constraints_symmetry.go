@@ -0,0 +1,314 @@
+package sketch
+
+import (
+	"fmt"
+
+	"unholy-cad/geom"
+)
+
+// mirrorPoint reflects p across the infinite line through a and b
+func mirrorPoint(p, a, b geom.Vec2) geom.Vec2 {
+	foot := projectOntoLine(p, a, b)
+	return foot.Mul(2).Sub(p)
+}
+
+// SketchConstraintSymmetric mirrors two points about the infinite line through a line's endpoints
+type SketchConstraintSymmetric struct {
+	Id       int `json:"id"`
+	Point1Id int `json:"point1Id"`
+	Point2Id int `json:"point2Id"`
+	LineId   int `json:"lineId"`
+}
+
+func (c *SketchConstraintSymmetric) Clone() SketchElement {
+	return &SketchConstraintSymmetric{
+		Id:       c.Id,
+		Point1Id: c.Point1Id,
+		Point2Id: c.Point2Id,
+		LineId:   c.LineId,
+	}
+}
+
+func (c *SketchConstraintSymmetric) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintSymmetric) GetReferences() []int {
+	return []int{c.Point1Id, c.Point2Id, c.LineId}
+}
+
+func (c *SketchConstraintSymmetric) GetDependencies(s *Sketch) ([]int, error) {
+	line, err := GetElementByID[*SketchLine](s, c.LineId)
+	if err != nil {
+		return nil, err
+	}
+	return []int{c.Point1Id, c.Point2Id, line.StartId, line.EndId}, nil
+}
+
+// GetResiduals returns the distance of the middle of both points from the line and how far
+// the connection between the points is from being perpendicular to the line
+func (c *SketchConstraintSymmetric) GetResiduals(s *Sketch) ([]float64, error) {
+	points, err := GetPoints(s, c.Point1Id, c.Point2Id)
+	if err != nil {
+		return nil, err
+	}
+	startPoint, endPoint, err := GetLinePoints(s, c.LineId)
+	if err != nil {
+		return nil, err
+	}
+
+	a, b := startPoint.Position, endPoint.Position
+	middle := points[0].Position.Lerp(points[1].Position, 0.5)
+	direction := b.Sub(a)
+	length := direction.Magnitude()
+	if length == 0 {
+		return []float64{middle.DistanceTo(a), 0}, nil
+	}
+	return []float64{
+		signedDistanceToLine(middle, a, b),
+		points[1].Position.Sub(points[0].Position).Dot(direction) / length,
+	}, nil
+}
+
+func (c *SketchConstraintSymmetric) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(residuals[0]) && isNearZero(residuals[1]), nil
+}
+
+func (c *SketchConstraintSymmetric) GetBranches() int {
+	return 2
+}
+
+func (c *SketchConstraintSymmetric) Apply(s *Sketch, branch int) (bool, error) {
+	points, err := GetPoints(s, c.Point1Id, c.Point2Id)
+	if err != nil {
+		return false, err
+	}
+	startPoint, endPoint, err := GetLinePoints(s, c.LineId)
+	if err != nil {
+		return false, err
+	}
+
+	if branch == 0 { // mirror point1 onto point2
+		points[1].Position = mirrorPoint(points[0].Position, startPoint.Position, endPoint.Position)
+	} else if branch == 1 { // mirror point2 onto point1
+		points[0].Position = mirrorPoint(points[1].Position, startPoint.Position, endPoint.Position)
+	}
+	return true, nil
+}
+
+// SketchConstraintMidpoint keeps a point at the middle of a line
+type SketchConstraintMidpoint struct {
+	Id      int `json:"id"`
+	PointId int `json:"pointId"`
+	LineId  int `json:"lineId"`
+}
+
+func (c *SketchConstraintMidpoint) Clone() SketchElement {
+	return &SketchConstraintMidpoint{
+		Id:      c.Id,
+		PointId: c.PointId,
+		LineId:  c.LineId,
+	}
+}
+
+func (c *SketchConstraintMidpoint) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintMidpoint) GetReferences() []int {
+	return []int{c.PointId, c.LineId}
+}
+
+func (c *SketchConstraintMidpoint) GetDependencies(s *Sketch) ([]int, error) {
+	line, err := GetElementByID[*SketchLine](s, c.LineId)
+	if err != nil {
+		return nil, err
+	}
+	return []int{c.PointId, line.StartId, line.EndId}, nil
+}
+
+func (c *SketchConstraintMidpoint) GetResiduals(s *Sketch) ([]float64, error) {
+	point, err := GetElementByID[*SketchPoint](s, c.PointId)
+	if err != nil {
+		return nil, err
+	}
+	startPoint, endPoint, err := GetLinePoints(s, c.LineId)
+	if err != nil {
+		return nil, err
+	}
+	offset := point.Position.Sub(startPoint.Position.Lerp(endPoint.Position, 0.5))
+	return []float64{offset.X, offset.Y}, nil
+}
+
+func (c *SketchConstraintMidpoint) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(residuals[0]) && isNearZero(residuals[1]), nil
+}
+
+func (c *SketchConstraintMidpoint) GetBranches() int {
+	return 2
+}
+
+func (c *SketchConstraintMidpoint) Apply(s *Sketch, branch int) (bool, error) {
+	point, err := GetElementByID[*SketchPoint](s, c.PointId)
+	if err != nil {
+		return false, err
+	}
+	startPoint, endPoint, err := GetLinePoints(s, c.LineId)
+	if err != nil {
+		return false, err
+	}
+
+	offset := startPoint.Position.Lerp(endPoint.Position, 0.5).Sub(point.Position)
+	if branch == 0 { // move the point to the middle
+		point.Position = point.Position.Add(offset)
+	} else if branch == 1 { // move the line so its middle is at the point
+		startPoint.Position = startPoint.Position.Sub(offset)
+		endPoint.Position = endPoint.Position.Sub(offset)
+	}
+	return true, nil
+}
+
+// SketchConstraintEqual keeps two lines at the same length or two circles and arcs at the
+// same radius, without fixing the size itself
+type SketchConstraintEqual struct {
+	Id         int `json:"id"`
+	Element1Id int `json:"element1Id"`
+	Element2Id int `json:"element2Id"`
+}
+
+func (c *SketchConstraintEqual) Clone() SketchElement {
+	return &SketchConstraintEqual{
+		Id:         c.Id,
+		Element1Id: c.Element1Id,
+		Element2Id: c.Element2Id,
+	}
+}
+
+func (c *SketchConstraintEqual) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintEqual) GetReferences() []int {
+	return []int{c.Element1Id, c.Element2Id}
+}
+
+// getSize returns the length of a line or the radius of a curve. The second element has to be
+// of the same kind as the first
+func (c *SketchConstraintEqual) getSize(s *Sketch, id int) (float64, error) {
+	first, err := GetElementByID[SketchElement](s, c.Element1Id)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, ok := first.(*SketchLine); ok {
+		startPoint, endPoint, err := GetLinePoints(s, id)
+		if err != nil {
+			return 0, err
+		}
+		return startPoint.Position.DistanceTo(endPoint.Position), nil
+	}
+	if _, ok := first.(SketchCurve); ok {
+		curve, err := GetCurve(s, id)
+		if err != nil {
+			return 0, err
+		}
+		return curve.GetRadius(s)
+	}
+	return 0, &ElementError{Id: c.Element1Id, Err: fmt.Errorf("%w: expected line, circle or arc, got %T", ErrTypeMismatch, first)}
+}
+
+// setSize scales a line around its start point or changes the radius of a curve
+func (c *SketchConstraintEqual) setSize(s *Sketch, id int, size float64) error {
+	element, err := GetElementByID[SketchElement](s, id)
+	if err != nil {
+		return err
+	}
+	switch e := element.(type) {
+	case *SketchLine:
+		startPoint, endPoint, err := GetLinePoints(s, e.Id)
+		if err != nil {
+			return err
+		}
+		direction := endPoint.Position.Sub(startPoint.Position)
+		if direction.Magnitude() == 0 {
+			return nil
+		}
+		endPoint.Position = startPoint.Position.Add(direction.Normalize().Mul(size))
+	case SketchCurve:
+		return setCurveRadius(s, e, size)
+	}
+	return nil
+}
+
+func (c *SketchConstraintEqual) getDependencies(s *Sketch, id int) ([]int, error) {
+	element, err := GetElementByID[SketchElement](s, id)
+	if err != nil {
+		return nil, err
+	}
+	if line, ok := element.(*SketchLine); ok {
+		return []int{line.StartId, line.EndId}, nil
+	}
+	return getCurveDependencies(s, id)
+}
+
+func (c *SketchConstraintEqual) GetDependencies(s *Sketch) ([]int, error) {
+	dependencies1, err := c.getDependencies(s, c.Element1Id)
+	if err != nil {
+		return nil, err
+	}
+	dependencies2, err := c.getDependencies(s, c.Element2Id)
+	if err != nil {
+		return nil, err
+	}
+	return append(dependencies1, dependencies2...), nil
+}
+
+func (c *SketchConstraintEqual) GetResiduals(s *Sketch) ([]float64, error) {
+	size1, err := c.getSize(s, c.Element1Id)
+	if err != nil {
+		return nil, err
+	}
+	size2, err := c.getSize(s, c.Element2Id)
+	if err != nil {
+		return nil, err
+	}
+	return []float64{size2 - size1}, nil
+}
+
+func (c *SketchConstraintEqual) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(residuals[0]), nil
+}
+
+func (c *SketchConstraintEqual) GetBranches() int {
+	return 2
+}
+
+func (c *SketchConstraintEqual) Apply(s *Sketch, branch int) (bool, error) {
+	size1, err := c.getSize(s, c.Element1Id)
+	if err != nil {
+		return false, err
+	}
+	size2, err := c.getSize(s, c.Element2Id)
+	if err != nil {
+		return false, err
+	}
+
+	if branch == 0 { // resize element2
+		err = c.setSize(s, c.Element2Id, size1)
+	} else if branch == 1 { // resize element1
+		err = c.setSize(s, c.Element1Id, size2)
+	}
+	return err == nil, err
+}
@@ -0,0 +1,282 @@
+package sketch
+
+import (
+	"fmt"
+	"math"
+
+	"unholy-cad/geom"
+)
+
+// SketchCurve is implemented by the round elements, circles and arcs
+type SketchCurve interface {
+	SketchElement
+	GetCenter(s *Sketch) (*SketchPoint, error)
+	GetRadius(s *Sketch) (float64, error)
+}
+
+// GetCurve resolves a circle or an arc
+func GetCurve(s *Sketch, id int) (SketchCurve, error) {
+	element, err := GetElementByID[SketchElement](s, id)
+	if err != nil {
+		return nil, err
+	}
+	curve, ok := element.(SketchCurve)
+	if !ok {
+		return nil, &ElementError{Id: id, Err: fmt.Errorf("%w: expected circle or arc, got %T", ErrTypeMismatch, element)}
+	}
+	return curve, nil
+}
+
+// getCurveDependencies returns the elements whose variables define the radius of a curve
+func getCurveDependencies(s *Sketch, curveId int) ([]int, error) {
+	curve, err := GetCurve(s, curveId)
+	if err != nil {
+		return nil, err
+	}
+	switch c := curve.(type) {
+	case *SketchCircle:
+		return []int{c.Id}, nil
+	case *SketchArc:
+		return []int{c.CenterId, c.StartId, c.EndId}, nil
+	}
+	return nil, nil
+}
+
+// getCurveShapeDependencies returns the elements whose variables define the position and
+// radius of a curve
+func getCurveShapeDependencies(s *Sketch, curveId int) ([]int, error) {
+	curve, err := GetCurve(s, curveId)
+	if err != nil {
+		return nil, err
+	}
+	dependencies, err := getCurveDependencies(s, curveId)
+	if err != nil {
+		return nil, err
+	}
+	center, err := curve.GetCenter(s)
+	if err != nil {
+		return nil, err
+	}
+	return append(dependencies, center.Id), nil
+}
+
+// getCurvePoints returns the points that move with a curve, the center of a circle
+// or all three points of an arc
+func getCurvePoints(s *Sketch, curve SketchCurve) ([]*SketchPoint, error) {
+	switch c := curve.(type) {
+	case *SketchCircle:
+		return GetPoints(s, c.CenterId)
+	case *SketchArc:
+		return GetPoints(s, c.CenterId, c.StartId, c.EndId)
+	}
+	return nil, nil
+}
+
+// translateCurve moves a curve by an offset without changing its shape
+func translateCurve(s *Sketch, curve SketchCurve, offset geom.Vec2) error {
+	points, err := getCurvePoints(s, curve)
+	if err != nil {
+		return err
+	}
+	for _, point := range points {
+		point.Position = point.Position.Add(offset)
+	}
+	return nil
+}
+
+// setCurveRadius changes the radius of a circle, or moves the endpoints of an arc
+// along their rays from the center
+func setCurveRadius(s *Sketch, curve SketchCurve, radius float64) error {
+	switch c := curve.(type) {
+	case *SketchCircle:
+		c.Radius = radius
+	case *SketchArc:
+		center, startPoint, endPoint, err := c.GetPoints(s)
+		if err != nil {
+			return err
+		}
+		for _, point := range []*SketchPoint{startPoint, endPoint} {
+			direction := point.Position.Sub(center.Position)
+			if direction.Magnitude() == 0 {
+				continue
+			}
+			point.Position = center.Position.Add(direction.Normalize().Mul(radius))
+		}
+	}
+	return nil
+}
+
+type SketchCircle struct {
+	Id       int     `json:"id"`
+	CenterId int     `json:"centerId"`
+	Radius   float64 `json:"radius"`
+}
+
+func (c *SketchCircle) Clone() SketchElement {
+	return &SketchCircle{
+		Id:       c.Id,
+		CenterId: c.CenterId,
+		Radius:   c.Radius,
+	}
+}
+
+func (c *SketchCircle) GetId() int {
+	return c.Id
+}
+
+func (c *SketchCircle) GetReferences() []int {
+	return []int{c.CenterId}
+}
+
+func (c *SketchCircle) GetCenter(s *Sketch) (*SketchPoint, error) {
+	return GetElementByID[*SketchPoint](s, c.CenterId)
+}
+
+func (c *SketchCircle) GetRadius(s *Sketch) (float64, error) {
+	return c.Radius, nil
+}
+
+func (c *SketchCircle) getVariables() []*float64 {
+	return []*float64{&c.Radius}
+}
+
+// SketchArc runs from its start point to its end point with increasing angle around
+// the center, which is clockwise on screen since y points down. Both endpoints have to
+// stay at the same distance from the center, which the arc adds as an implicit constraint
+type SketchArc struct {
+	Id       int `json:"id"`
+	CenterId int `json:"centerId"`
+	StartId  int `json:"startId"`
+	EndId    int `json:"endId"`
+}
+
+func (a *SketchArc) Clone() SketchElement {
+	return &SketchArc{
+		Id:       a.Id,
+		CenterId: a.CenterId,
+		StartId:  a.StartId,
+		EndId:    a.EndId,
+	}
+}
+
+func (a *SketchArc) GetId() int {
+	return a.Id
+}
+
+func (a *SketchArc) GetReferences() []int {
+	return []int{a.CenterId, a.StartId, a.EndId}
+}
+
+// GetPoints resolves the center, start and end points
+func (a *SketchArc) GetPoints(s *Sketch) (*SketchPoint, *SketchPoint, *SketchPoint, error) {
+	points, err := GetPoints(s, a.CenterId, a.StartId, a.EndId)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return points[0], points[1], points[2], nil
+}
+
+func (a *SketchArc) GetCenter(s *Sketch) (*SketchPoint, error) {
+	return GetElementByID[*SketchPoint](s, a.CenterId)
+}
+
+// GetRadius returns the distance from the center to the start point
+func (a *SketchArc) GetRadius(s *Sketch) (float64, error) {
+	center, startPoint, _, err := a.GetPoints(s)
+	if err != nil {
+		return 0, err
+	}
+	return center.Position.DistanceTo(startPoint.Position), nil
+}
+
+// GetAngles returns the angle of the start point in radians and the sweep to the end point,
+// between 0 and 2π
+func (a *SketchArc) GetAngles(s *Sketch) (float64, float64, error) {
+	center, startPoint, endPoint, err := a.GetPoints(s)
+	if err != nil {
+		return 0, 0, err
+	}
+	v1 := startPoint.Position.Sub(center.Position)
+	v2 := endPoint.Position.Sub(center.Position)
+	start := math.Atan2(v1.Y, v1.X)
+	sweep := math.Atan2(v2.Y, v2.X) - start
+	if sweep < 0 {
+		sweep += 2 * math.Pi
+	}
+	return start, sweep, nil
+}
+
+// GetDirectionAt returns the unit direction pointing into the arc from one of its endpoints
+func (a *SketchArc) GetDirectionAt(s *Sketch, pointId int) (geom.Vec2, error) {
+	center, startPoint, endPoint, err := a.GetPoints(s)
+	if err != nil {
+		return geom.Vec2{}, err
+	}
+	switch pointId {
+	case a.StartId:
+		return startPoint.Position.Sub(center.Position).Tangent().Normalize(), nil
+	case a.EndId:
+		return endPoint.Position.Sub(center.Position).Tangent().Normalize().Mul(-1), nil
+	}
+	return geom.Vec2{}, &ElementError{Id: pointId, Err: fmt.Errorf("%w: not an endpoint of arc %d", ErrNoSharedPoint, a.Id)}
+}
+
+// GetImplicitConstraints keeps the end point on the circle through the start point
+func (a *SketchArc) GetImplicitConstraints() []SketchConstraint {
+	return []SketchConstraint{&arcRadius{arc: a}}
+}
+
+// arcRadius is the implicit constraint of an arc. It has the id of the arc
+type arcRadius struct {
+	arc *SketchArc
+}
+
+func (c *arcRadius) Clone() SketchElement {
+	return &arcRadius{arc: c.arc.Clone().(*SketchArc)}
+}
+
+func (c *arcRadius) GetId() int {
+	return c.arc.Id
+}
+
+func (c *arcRadius) GetReferences() []int {
+	return c.arc.GetReferences()
+}
+
+func (c *arcRadius) GetDependencies(s *Sketch) ([]int, error) {
+	return []int{c.arc.CenterId, c.arc.StartId, c.arc.EndId}, nil
+}
+
+func (c *arcRadius) GetResiduals(s *Sketch) ([]float64, error) {
+	center, startPoint, endPoint, err := c.arc.GetPoints(s)
+	if err != nil {
+		return nil, err
+	}
+	return []float64{center.Position.DistanceTo(endPoint.Position) - center.Position.DistanceTo(startPoint.Position)}, nil
+}
+
+func (c *arcRadius) IsSatisfied(s *Sketch) (bool, error) {
+	residuals, err := c.GetResiduals(s)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(residuals[0]), nil
+}
+
+func (c *arcRadius) GetBranches() int {
+	return 1
+}
+
+// Apply moves the end point onto the radius of the start point
+func (c *arcRadius) Apply(s *Sketch, branch int) (bool, error) {
+	center, startPoint, endPoint, err := c.arc.GetPoints(s)
+	if err != nil {
+		return false, err
+	}
+	direction := endPoint.Position.Sub(center.Position)
+	if direction.Magnitude() == 0 {
+		return false, nil
+	}
+	endPoint.Position = center.Position.Add(direction.Normalize().Mul(center.Position.DistanceTo(startPoint.Position)))
+	return true, nil
+}
@@ -0,0 +1,145 @@
+package sketch
+
+import (
+	"sort"
+)
+
+const dofTolerance = 1e-6
+
+type ConstraintState int
+
+const (
+	UnderConstrained ConstraintState = iota
+	FullyConstrained
+	OverConstrained
+)
+
+type DofAnalysis struct {
+	// remaining degrees of freedom of the whole sketch
+	Dof int
+	// remaining degrees of freedom of each point, line, circle and arc
+	ElementDof   map[int]int
+	ElementState map[int]ConstraintState
+	// constraints whose equations are implied by the constraints before them
+	Redundant []int
+	// redundant constraints that are not satisfied and therefore contradict the others
+	Conflicting []int
+	// constraints with broken element references, they are left out of the analysis
+	Invalid map[int]error
+}
+
+// GetState returns the state of the sketch as a whole
+func (a *DofAnalysis) GetState() ConstraintState {
+	if len(a.Redundant) > 0 {
+		return OverConstrained
+	} else if a.Dof > 0 {
+		return UnderConstrained
+	}
+	return FullyConstrained
+}
+
+func (a *DofAnalysis) GetElementState(id int) ConstraintState {
+	if state, ok := a.ElementState[id]; ok {
+		return state
+	}
+	return UnderConstrained
+}
+
+// AnalyzeDegreesOfFreedom derives the remaining freedom of the sketch from the rank
+// of the constraint jacobian at the current positions
+func (s *Sketch) AnalyzeDegreesOfFreedom() (DofAnalysis, error) {
+	constraints, invalid := s.validateConstraints()
+	sys, err := newSolverSystem(s, constraints, nil)
+	if err != nil {
+		return DofAnalysis{}, err
+	}
+	jacobian, err := sys.jacobian()
+	if err != nil {
+		return DofAnalysis{}, err
+	}
+	variableCount := len(sys.variables)
+
+	analysis := DofAnalysis{
+		Dof:          variableCount - matrixRank(jacobian, dofTolerance),
+		ElementDof:   map[int]int{},
+		ElementState: map[int]ConstraintState{},
+		Redundant:    make([]int, 0),
+		Conflicting:  make([]int, 0),
+		Invalid:      invalid,
+	}
+
+	// a constraint is redundant when none of its rows add rank to the rows before it
+	overConstrainedPoints := map[int]bool{}
+	basis := rowBasis{tolerance: dofTolerance}
+	for i, constraint := range constraints {
+		independent := true
+		for k := 0; k < sys.rowsOf(i); k++ {
+			if !basis.add(jacobian[sys.rows[i]+k]) {
+				independent = false
+			}
+		}
+		if independent || isImplicit(constraint) {
+			continue
+		}
+
+		analysis.Redundant = append(analysis.Redundant, constraint.GetId())
+		satisfied, err := constraint.IsSatisfied(s)
+		if err != nil {
+			return DofAnalysis{}, err
+		}
+		if !satisfied {
+			analysis.Conflicting = append(analysis.Conflicting, constraint.GetId())
+		}
+		for _, id := range sys.dependencies[i] {
+			overConstrainedPoints[id] = true
+		}
+	}
+
+	// the freedom left to an element is the rank of the null space restricted to its variables
+	nullBasis := nullSpace(jacobian, variableCount, dofTolerance)
+	elementDof := func(columns []int) int {
+		projected := newMatrix(len(nullBasis), len(columns))
+		for i, v := range nullBasis {
+			for j, col := range columns {
+				projected[i][j] = v[col]
+			}
+		}
+		return matrixRank(projected, dofTolerance)
+	}
+
+	columns := sys.elementColumns
+	for _, element := range s.Elements {
+		var elementColumns []int
+		over := false
+		switch e := element.(type) {
+		case *SketchPoint:
+			elementColumns = columns[e.Id]
+			over = overConstrainedPoints[e.Id]
+		case *SketchLine:
+			elementColumns = append(append([]int{}, columns[e.StartId]...), columns[e.EndId]...)
+			over = overConstrainedPoints[e.StartId] || overConstrainedPoints[e.EndId]
+		case *SketchCircle:
+			elementColumns = append(append([]int{}, columns[e.CenterId]...), columns[e.Id]...)
+			over = overConstrainedPoints[e.CenterId] || overConstrainedPoints[e.Id]
+		case *SketchArc:
+			elementColumns = append(append(append([]int{}, columns[e.CenterId]...), columns[e.StartId]...), columns[e.EndId]...)
+			over = overConstrainedPoints[e.CenterId] || overConstrainedPoints[e.StartId] || overConstrainedPoints[e.EndId]
+		default:
+			continue
+		}
+
+		dof := elementDof(elementColumns)
+		analysis.ElementDof[element.GetId()] = dof
+		if over {
+			analysis.ElementState[element.GetId()] = OverConstrained
+		} else if dof > 0 {
+			analysis.ElementState[element.GetId()] = UnderConstrained
+		} else {
+			analysis.ElementState[element.GetId()] = FullyConstrained
+		}
+	}
+
+	sort.Ints(analysis.Redundant)
+	sort.Ints(analysis.Conflicting)
+	return analysis, nil
+}
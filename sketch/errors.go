@@ -0,0 +1,51 @@
+package sketch
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrElementNotFound = errors.New("element not found")
+	ErrTypeMismatch    = errors.New("element type mismatch")
+	ErrNoSharedPoint   = errors.New("elements do not share an endpoint")
+
+	ErrInvalidExpression = errors.New("invalid expression")
+	ErrInvalidName       = errors.New("invalid name")
+	ErrUnknownName       = errors.New("unknown name")
+	ErrDuplicateName     = errors.New("duplicate name")
+	ErrReservedName      = errors.New("names of the form d<number> are reserved for dimensions")
+	ErrCycle             = errors.New("circular reference")
+
+	ErrReferenceExpression = errors.New("reference dimensions are measured and cannot have an expression")
+	ErrUnitMismatch        = errors.New("unit does not fit the dimension")
+	ErrNotPositive         = errors.New("lengths and distances have to be positive")
+)
+
+// ElementError is returned when an element id cannot be resolved or does not fit a constraint
+type ElementError struct {
+	Id  int
+	Err error
+}
+
+func (e *ElementError) Error() string {
+	return fmt.Sprintf("element %d: %v", e.Id, e.Err)
+}
+
+func (e *ElementError) Unwrap() error {
+	return e.Err
+}
+
+// ParameterError is returned when a parameter or dimension expression cannot be evaluated
+type ParameterError struct {
+	Name string
+	Err  error
+}
+
+func (e *ParameterError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Name, e.Err)
+}
+
+func (e *ParameterError) Unwrap() error {
+	return e.Err
+}
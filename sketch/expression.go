@@ -0,0 +1,524 @@
+package sketch
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// expression is a parsed arithmetic expression over named values
+type expression interface {
+	evaluate(values map[string]float64) (float64, error)
+	// collectNames adds the names the expression refers to
+	collectNames(names map[string]bool)
+	// quantity is what the value measures, given what the named values measure
+	quantity(quantities map[string]quantity) quantity
+}
+
+// quantity is what a value measures, taken from the units written in an expression and
+// carried through the names it refers to
+type quantity int
+
+const (
+	// a plain number, it takes the units of whatever it is used for
+	quantityNone quantity = iota
+	quantityLength
+	quantityAngle
+	// anything else, like an area or a length plus an angle, it fits no dimension
+	quantityMixed
+)
+
+func (q quantity) String() string {
+	switch q {
+	case quantityNone:
+		return "number"
+	case quantityLength:
+		return "length"
+	case quantityAngle:
+		return "angle"
+	}
+	return "mixed units"
+}
+
+// sumQuantity is the quantity of a sum, plain numbers take the units of the other term
+func sumQuantity(a, b quantity) quantity {
+	switch {
+	case a == quantityNone:
+		return b
+	case b == quantityNone || a == b:
+		return a
+	}
+	return quantityMixed
+}
+
+type numberExpression float64
+
+func (e numberExpression) evaluate(values map[string]float64) (float64, error) {
+	return float64(e), nil
+}
+
+func (e numberExpression) collectNames(names map[string]bool) {}
+
+func (e numberExpression) quantity(quantities map[string]quantity) quantity {
+	return quantityNone
+}
+
+type nameExpression string
+
+func (e nameExpression) evaluate(values map[string]float64) (float64, error) {
+	if e == "pi" {
+		return math.Pi, nil
+	}
+	value, ok := values[string(e)]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownName, string(e))
+	}
+	return value, nil
+}
+
+func (e nameExpression) collectNames(names map[string]bool) {
+	if e != "pi" {
+		names[string(e)] = true
+	}
+}
+
+func (e nameExpression) quantity(quantities map[string]quantity) quantity {
+	return quantities[string(e)]
+}
+
+type unaryExpression struct {
+	operand expression
+}
+
+func (e *unaryExpression) evaluate(values map[string]float64) (float64, error) {
+	value, err := e.operand.evaluate(values)
+	return -value, err
+}
+
+func (e *unaryExpression) collectNames(names map[string]bool) {
+	e.operand.collectNames(names)
+}
+
+func (e *unaryExpression) quantity(quantities map[string]quantity) quantity {
+	return e.operand.quantity(quantities)
+}
+
+type binaryExpression struct {
+	operator    byte
+	left, right expression
+}
+
+func (e *binaryExpression) evaluate(values map[string]float64) (float64, error) {
+	left, err := e.left.evaluate(values)
+	if err != nil {
+		return 0, err
+	}
+	right, err := e.right.evaluate(values)
+	if err != nil {
+		return 0, err
+	}
+	switch e.operator {
+	case '+':
+		return left + right, nil
+	case '-':
+		return left - right, nil
+	case '*':
+		return left * right, nil
+	case '/':
+		if right == 0 {
+			return 0, fmt.Errorf("%w: division by zero", ErrInvalidExpression)
+		}
+		return left / right, nil
+	case '^':
+		return math.Pow(left, right), nil
+	}
+	return 0, fmt.Errorf("%w: unknown operator %q", ErrInvalidExpression, e.operator)
+}
+
+func (e *binaryExpression) collectNames(names map[string]bool) {
+	e.left.collectNames(names)
+	e.right.collectNames(names)
+}
+
+func (e *binaryExpression) quantity(quantities map[string]quantity) quantity {
+	left, right := e.left.quantity(quantities), e.right.quantity(quantities)
+	switch e.operator {
+	case '*':
+		if left == quantityNone || right == quantityNone {
+			return sumQuantity(left, right)
+		}
+	case '/':
+		// a ratio of two lengths or two angles is a plain number
+		if right == quantityNone {
+			return left
+		}
+		if left == right && left != quantityMixed {
+			return quantityNone
+		}
+	case '^':
+		if left == quantityNone && right == quantityNone {
+			return quantityNone
+		}
+	default:
+		return sumQuantity(left, right)
+	}
+	return quantityMixed
+}
+
+// unitExpression is a value followed by a unit, scaled to the units of the sketch
+type unitExpression struct {
+	value expression
+	unit  string
+}
+
+func (e *unitExpression) evaluate(values map[string]float64) (float64, error) {
+	value, err := e.value.evaluate(values)
+	return value * expressionUnits[e.unit], err
+}
+
+func (e *unitExpression) collectNames(names map[string]bool) {
+	e.value.collectNames(names)
+}
+
+func (e *unitExpression) quantity(quantities map[string]quantity) quantity {
+	unit := quantityLength
+	if angleUnits[e.unit] {
+		unit = quantityAngle
+	}
+	if e.value.quantity(quantities) != quantityNone {
+		// a unit after a value that already has one, like (1in)mm
+		return quantityMixed
+	}
+	return unit
+}
+
+// expression functions, trigonometry works in degrees like the angle dimensions
+var expressionFunctions = map[string]func(args []float64) (float64, error){
+	"sqrt": unaryFunction(math.Sqrt),
+	"abs":  unaryFunction(math.Abs),
+	"sin":  unaryFunction(func(x float64) float64 { return math.Sin(x * math.Pi / 180) }),
+	"cos":  unaryFunction(func(x float64) float64 { return math.Cos(x * math.Pi / 180) }),
+	"tan":  unaryFunction(func(x float64) float64 { return math.Tan(x * math.Pi / 180) }),
+	"asin": unaryFunction(func(x float64) float64 { return math.Asin(x) * 180 / math.Pi }),
+	"acos": unaryFunction(func(x float64) float64 { return math.Acos(x) * 180 / math.Pi }),
+	"atan": unaryFunction(func(x float64) float64 { return math.Atan(x) * 180 / math.Pi }),
+	"min": func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, fmt.Errorf("%w: min needs an argument", ErrInvalidExpression)
+		}
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Min(result, arg)
+		}
+		return result, nil
+	},
+	"max": func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, fmt.Errorf("%w: max needs an argument", ErrInvalidExpression)
+		}
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Max(result, arg)
+		}
+		return result, nil
+	},
+}
+
+// units that can follow a number or a parenthesized expression, as factors to the units
+// of the sketch: millimeters for lengths and degrees for angles
+var expressionUnits = map[string]float64{
+	"mm":  1,
+	"cm":  10,
+	"m":   1000,
+	"in":  25.4,
+	"deg": 1,
+	"°":   1,
+	"rad": 180 / math.Pi,
+}
+
+// units of angles, all other units are lengths
+var angleUnits = map[string]bool{"deg": true, "°": true, "rad": true}
+
+func unaryFunction(f func(float64) float64) func(args []float64) (float64, error) {
+	return func(args []float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("%w: expected 1 argument, got %d", ErrInvalidExpression, len(args))
+		}
+		return f(args[0]), nil
+	}
+}
+
+type callExpression struct {
+	function string
+	args     []expression
+}
+
+func (e *callExpression) evaluate(values map[string]float64) (float64, error) {
+	args := make([]float64, len(e.args))
+	for i, arg := range e.args {
+		value, err := arg.evaluate(values)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = value
+	}
+	value, err := expressionFunctions[e.function](args)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", e.function, err)
+	}
+	return value, nil
+}
+
+func (e *callExpression) collectNames(names map[string]bool) {
+	for _, arg := range e.args {
+		arg.collectNames(names)
+	}
+}
+
+// quantity of a call, abs, min and max keep the units of their arguments, the other
+// functions give plain numbers, cos(30°) included
+func (e *callExpression) quantity(quantities map[string]quantity) quantity {
+	switch e.function {
+	case "abs", "min", "max":
+		result := quantityNone
+		for _, arg := range e.args {
+			result = sumQuantity(result, arg.quantity(quantities))
+		}
+		return result
+	}
+	return quantityNone
+}
+
+// isIdentifier reports whether a string can be used as a parameter or dimension name
+func isIdentifier(name string) bool {
+	if _, unit := expressionUnits[name]; name == "" || name == "pi" || unit || expressionFunctions[name] != nil {
+		return false
+	}
+	for i, r := range name {
+		if !(r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return true
+}
+
+// expressionParser is a recursive descent parser over the source text
+//
+//	sum     = product { ("+" | "-") product }
+//	product = unary { ("*" | "/") unary }
+//	unary   = ("-" | "+") unary | power
+//	power   = primary [ "^" unary ]
+//	primary = number [ unit ] | name | name "(" [ sum { "," sum } ] ")" | "(" sum ")" [ unit ]
+type expressionParser struct {
+	source string
+	pos    int
+}
+
+func parseExpression(source string) (expression, error) {
+	p := &expressionParser{source: source}
+	e, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.source) {
+		return nil, p.errorf("unexpected %q", p.source[p.pos:])
+	}
+	return e, nil
+}
+
+func (p *expressionParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w at %d: %s", ErrInvalidExpression, p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *expressionParser) skipSpace() {
+	for p.pos < len(p.source) && p.source[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// accept consumes the next character when it is one of the given ones
+func (p *expressionParser) accept(chars string) (byte, bool) {
+	p.skipSpace()
+	if p.pos < len(p.source) && strings.IndexByte(chars, p.source[p.pos]) >= 0 {
+		p.pos++
+		return p.source[p.pos-1], true
+	}
+	return 0, false
+}
+
+func (p *expressionParser) parseSum() (expression, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.accept("+-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpression{operator: operator, left: left, right: right}
+	}
+}
+
+func (p *expressionParser) parseProduct() (expression, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.accept("*/")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpression{operator: operator, left: left, right: right}
+	}
+}
+
+func (p *expressionParser) parseUnary() (expression, error) {
+	if operator, ok := p.accept("+-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if operator == '-' {
+			return &unaryExpression{operand: operand}, nil
+		}
+		return operand, nil
+	}
+	return p.parsePower()
+}
+
+func (p *expressionParser) parsePower() (expression, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("^"); ok {
+		exponent, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryExpression{operator: '^', left: base, right: exponent}, nil
+	}
+	return base, nil
+}
+
+func (p *expressionParser) parsePrimary() (expression, error) {
+	p.skipSpace()
+	if p.pos >= len(p.source) {
+		return nil, p.errorf("unexpected end of expression")
+	}
+
+	if _, ok := p.accept("("); ok {
+		e, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, p.errorf("expected )")
+		}
+		return p.parseUnit(e), nil
+	}
+
+	c, _ := utf8.DecodeRuneInString(p.source[p.pos:])
+	if c >= '0' && c <= '9' || c == '.' {
+		number, err := p.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+		return p.parseUnit(number), nil
+	}
+	if c == '_' || unicode.IsLetter(c) {
+		name := p.parseName()
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(name)
+		}
+		return nameExpression(name), nil
+	}
+	return nil, p.errorf("unexpected %q", string(c))
+}
+
+func (p *expressionParser) parseNumber() (expression, error) {
+	start := p.pos
+	for p.pos < len(p.source) {
+		c := p.source[p.pos]
+		isExponentSign := (c == '+' || c == '-') && p.pos > start && (p.source[p.pos-1] == 'e' || p.source[p.pos-1] == 'E')
+		if !(c >= '0' && c <= '9' || c == '.' || c == 'e' || c == 'E' || isExponentSign) {
+			break
+		}
+		p.pos++
+	}
+	text := p.source[start:p.pos]
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("invalid number %q", text)
+	}
+	return numberExpression(value), nil
+}
+
+// parseUnit scales a value by the unit written after it, if there is one
+func (p *expressionParser) parseUnit(value expression) expression {
+	start := p.pos
+	p.skipSpace()
+	unit := "°"
+	if !strings.HasPrefix(p.source[p.pos:], unit) {
+		unit = p.parseName()
+	} else {
+		p.pos += len(unit)
+	}
+	if _, ok := expressionUnits[unit]; !ok {
+		p.pos = start
+		return value
+	}
+	return &unitExpression{value: value, unit: unit}
+}
+
+func (p *expressionParser) parseName() string {
+	start := p.pos
+	for p.pos < len(p.source) {
+		// names may use any letter, not only ASCII ones
+		c, size := utf8.DecodeRuneInString(p.source[p.pos:])
+		if !(c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)) {
+			break
+		}
+		p.pos += size
+	}
+	return p.source[start:p.pos]
+}
+
+func (p *expressionParser) parseCall(function string) (expression, error) {
+	if expressionFunctions[function] == nil {
+		return nil, p.errorf("unknown function %s", function)
+	}
+	call := &callExpression{function: function}
+	if _, ok := p.accept(")"); ok {
+		return call, nil
+	}
+	for {
+		arg, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		if _, ok := p.accept(","); ok {
+			continue
+		}
+		if _, ok := p.accept(")"); ok {
+			return call, nil
+		}
+		return nil, p.errorf("expected , or )")
+	}
+}
@@ -0,0 +1,131 @@
+package sketch
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestExpressionEvaluate(t *testing.T) {
+	values := map[string]float64{"w": 40, "h": 10, "längd": 3}
+	tests := []struct {
+		source string
+		want   float64
+	}{
+		// precedence and associativity
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"24 / 4 / 2", 3},
+		{"2 ^ 3 ^ 2", 512},
+		{"2 * 3 ^ 2", 18},
+		{"w / 2 + h", 30},
+
+		// unary minus binds looser than ^ and tighter than * and /
+		{"-3", -3},
+		{"--3", 3},
+		{"+3", 3},
+		{"-2 ^ 2", -4},
+		{"2 ^ -1", 0.5},
+		{"4 * -h", -40},
+		{"-(w - h)", -30},
+
+		// units scale to millimeters and degrees
+		{"5mm", 5},
+		{"2cm", 20},
+		{"1.5 m", 1500},
+		{"1in", 25.4},
+		{"2 * 1in", 50.8},
+		{"(1 + 1)in", 50.8},
+		{"30deg", 30},
+		{"30°", 30},
+		{"(pi / 2) rad", 90},
+		{"1rad", 180 / math.Pi},
+		{"cos(60°)", 0.5},
+
+		// names and functions
+		{"längd * 2", 6},
+		{"sqrt(16)", 4},
+		{"max(w, h, 50)", 50},
+		{"min(w, h)", 10},
+		{"1e2", 100},
+		{"2.5e-1", 0.25},
+	}
+	for _, test := range tests {
+		e, err := parseExpression(test.source)
+		if err != nil {
+			t.Errorf("%q: %v", test.source, err)
+			continue
+		}
+		got, err := e.evaluate(values)
+		if err != nil {
+			t.Errorf("%q: %v", test.source, err)
+			continue
+		}
+		if math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%q = %v, want %v", test.source, got, test.want)
+		}
+	}
+}
+
+func TestExpressionQuantity(t *testing.T) {
+	quantities := map[string]quantity{"w": quantityLength, "a": quantityAngle, "n": quantityNone}
+	tests := []struct {
+		source string
+		want   quantity
+	}{
+		{"2", quantityNone},
+		{"2in", quantityLength},
+		{"(pi / 2) rad", quantityAngle},
+		{"w", quantityLength},
+		{"-w * 2 + 5", quantityLength},
+		{"n * 2", quantityNone},
+		{"w / 2mm", quantityNone},
+		{"a / 2", quantityAngle},
+		{"max(w, 10cm)", quantityLength},
+		{"cos(a) * w", quantityLength},
+		{"w + a", quantityMixed},
+		{"w * w", quantityMixed},
+		{"2 / w", quantityMixed},
+		{"w ^ 2", quantityMixed},
+		{"(1in)mm", quantityMixed},
+		{"min(w, 30deg)", quantityMixed},
+	}
+	for _, test := range tests {
+		e, err := parseExpression(test.source)
+		if err != nil {
+			t.Errorf("%q: %v", test.source, err)
+			continue
+		}
+		if got := e.quantity(quantities); got != test.want {
+			t.Errorf("%q is a %v, want %v", test.source, got, test.want)
+		}
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   error
+	}{
+		{"", ErrInvalidExpression},
+		{"1 +", ErrInvalidExpression},
+		{"(1 + 2", ErrInvalidExpression},
+		{"1 2", ErrInvalidExpression},
+		{"2 # 3", ErrInvalidExpression},
+		{"foo(1)", ErrInvalidExpression},
+		{"sqrt(1, 2)", ErrInvalidExpression},
+		{"1 / 0", ErrInvalidExpression},
+		{"w + 1", ErrUnknownName},
+		{"2 * depth", ErrUnknownName},
+	}
+	for _, test := range tests {
+		e, err := parseExpression(test.source)
+		if err == nil {
+			_, err = e.evaluate(nil)
+		}
+		if !errors.Is(err, test.want) {
+			t.Errorf("%q: got %v, want %v", test.source, err, test.want)
+		}
+	}
+}
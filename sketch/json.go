@@ -0,0 +1,178 @@
+package sketch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+)
+
+// DocumentVersion is the version written to saved sketches, documents with a
+// newer version are rejected on load. Version 2 measures corner angles signed, from 0 to 360
+const DocumentVersion = 2
+
+// element kinds as they appear in the "type" field of a saved element
+var elementKinds = map[string]func() SketchElement{
+	"point":             func() SketchElement { return &SketchPoint{} },
+	"line":              func() SketchElement { return &SketchLine{} },
+	"circle":            func() SketchElement { return &SketchCircle{} },
+	"arc":               func() SketchElement { return &SketchArc{} },
+	"cornerAngle":       func() SketchElement { return &SketchConstraintCornerAngle{} },
+	"lineLength":        func() SketchElement { return &SketchConstraintLineLength{} },
+	"coincident":        func() SketchElement { return &SketchConstraintCoincident{} },
+	"horizontal":        func() SketchElement { return &SketchConstraintHorizontal{} },
+	"vertical":          func() SketchElement { return &SketchConstraintVertical{} },
+	"parallel":          func() SketchElement { return &SketchConstraintParallel{} },
+	"perpendicular":     func() SketchElement { return &SketchConstraintPerpendicular{} },
+	"lineAngle":         func() SketchElement { return &SketchConstraintLineAngle{} },
+	"radius":            func() SketchElement { return &SketchConstraintRadius{} },
+	"diameter":          func() SketchElement { return &SketchConstraintDiameter{} },
+	"tangent":           func() SketchElement { return &SketchConstraintTangent{} },
+	"arcTangent":        func() SketchElement { return &SketchConstraintArcTangent{} },
+	"concentric":        func() SketchElement { return &SketchConstraintConcentric{} },
+	"pointOnCurve":      func() SketchElement { return &SketchConstraintPointOnCurve{} },
+	"pointOnLine":       func() SketchElement { return &SketchConstraintPointOnLine{} },
+	"symmetric":         func() SketchElement { return &SketchConstraintSymmetric{} },
+	"midpoint":          func() SketchElement { return &SketchConstraintMidpoint{} },
+	"equal":             func() SketchElement { return &SketchConstraintEqual{} },
+	"fixed":             func() SketchElement { return &SketchConstraintFixed{} },
+	"pointDistance":     func() SketchElement { return &SketchConstraintPointDistance{} },
+	"pointLineDistance": func() SketchElement { return &SketchConstraintPointLineDistance{} },
+}
+
+type document struct {
+	Version    int               `json:"version"`
+	Parameters []*Parameter      `json:"parameters,omitempty"`
+	Elements   []json.RawMessage `json:"elements"`
+}
+
+func elementKind(element SketchElement) (string, error) {
+	elementType := reflect.TypeOf(element)
+	for kind, newElement := range elementKinds {
+		if reflect.TypeOf(newElement()) == elementType {
+			return kind, nil
+		}
+	}
+	return "", fmt.Errorf("element %d: unsupported element type %T", element.GetId(), element)
+}
+
+func (s *Sketch) MarshalJSON() ([]byte, error) {
+	doc := document{
+		Version:    DocumentVersion,
+		Parameters: s.Parameters,
+		Elements:   make([]json.RawMessage, 0, len(s.Elements)),
+	}
+
+	for _, element := range s.Elements {
+		// the origin and axes are recreated on load
+		if IsBuiltin(element.GetId()) {
+			continue
+		}
+		kind, err := elementKind(element)
+		if err != nil {
+			return nil, err
+		}
+		fields, err := json.Marshal(element)
+		if err != nil {
+			return nil, err
+		}
+
+		// prepend the kind to the element's own fields
+		var data bytes.Buffer
+		data.WriteString(`{"type":` + strconv.Quote(kind))
+		if len(fields) > 2 {
+			data.WriteString(",")
+		}
+		data.Write(fields[1:])
+		doc.Elements = append(doc.Elements, data.Bytes())
+	}
+
+	return json.Marshal(doc)
+}
+
+func (s *Sketch) UnmarshalJSON(data []byte) error {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc.Version < 1 || doc.Version > DocumentVersion {
+		return fmt.Errorf("unsupported sketch version %d", doc.Version)
+	}
+
+	elements := make([]SketchElement, 0, len(doc.Elements))
+	ids := map[int]bool{}
+	for i, raw := range doc.Elements {
+		var header struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(raw, &header); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+		newElement, ok := elementKinds[header.Type]
+		if !ok {
+			return fmt.Errorf("element %d: unknown element type %q", i, header.Type)
+		}
+
+		element := newElement()
+		if err := json.Unmarshal(raw, element); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+		if IsBuiltin(element.GetId()) {
+			return fmt.Errorf("element %d: id %d is reserved for the origin", i, element.GetId())
+		}
+		if ids[element.GetId()] {
+			return fmt.Errorf("element %d: duplicate id %d", i, element.GetId())
+		}
+		ids[element.GetId()] = true
+		elements = append(elements, element)
+	}
+
+	s.Elements = elements
+	s.Parameters = doc.Parameters
+	s.EnsureOrigin()
+	if doc.Version < 2 {
+		s.signCornerAngles()
+	}
+	return s.EvaluateParameters()
+}
+
+// signCornerAngles turns the unsigned corner angles of version 1 documents into signed
+// ones. Corners that open clockwise get their line points swapped, so they read the same
+// angle as before and the shape stays the same, whether the value is a number or an expression
+func (s *Sketch) signCornerAngles() {
+	for _, element := range s.Elements {
+		c, ok := element.(*SketchConstraintCornerAngle)
+		if !ok {
+			continue
+		}
+		current, err := c.GetCurrentAngle(s)
+		if err == nil && current > 180 {
+			c.LinePoint1Id, c.LinePoint2Id = c.LinePoint2Id, c.LinePoint1Id
+		}
+	}
+}
+
+// Load reads a sketch document from a file
+func Load(path string) (*Sketch, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Sketch{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes the sketch as an indented document so it diffs well under version control
+func (s *Sketch) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
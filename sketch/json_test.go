@@ -0,0 +1,73 @@
+package sketch
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// clockwiseCorners has a plain and an expression driven corner angle, both on corners
+// that read more than 180 degrees when measured signed
+const clockwiseCorners = `{
+	"version": %d,
+	"parameters": [{"name": "a", "expression": "60"}],
+	"elements": [
+		{"type": "point", "id": 0, "position": {"x": 0, "y": 0}},
+		{"type": "point", "id": 1, "position": {"x": 0, "y": 10}},
+		{"type": "point", "id": 2, "position": {"x": 10, "y": 0}},
+		{"type": "line", "id": 3, "startId": 0, "endId": 1},
+		{"type": "line", "id": 4, "startId": 0, "endId": 2},
+		{"type": "cornerAngle", "id": 5, "cornerPointId": 0, "linePoint1Id": 1, "linePoint2Id": 2, "angle": 90},
+		{"type": "point", "id": 6, "position": {"x": 20, "y": 0}},
+		{"type": "point", "id": 7, "position": {"x": 25, "y": 8.660254037844386}},
+		{"type": "point", "id": 8, "position": {"x": 30, "y": 0}},
+		{"type": "line", "id": 9, "startId": 6, "endId": 7},
+		{"type": "line", "id": 10, "startId": 6, "endId": 8},
+		{"type": "cornerAngle", "id": 11, "cornerPointId": 6, "linePoint1Id": 7, "linePoint2Id": 8, "angle": 0, "expression": "a"}
+	]
+}`
+
+func TestLoadVersion1CornerAngles(t *testing.T) {
+	var current Sketch
+	if err := json.Unmarshal([]byte(fmt.Sprintf(clockwiseCorners, DocumentVersion)), &current); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int{5, 11} {
+		corner, err := GetElementByID[*SketchConstraintCornerAngle](&current, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if angle, err := corner.GetCurrentAngle(&current); err != nil || angle <= 180 {
+			t.Fatalf("corner %d of the fixture reads %v, %v, want a clockwise corner", id, angle, err)
+		}
+	}
+
+	var s Sketch
+	if err := json.Unmarshal([]byte(fmt.Sprintf(clockwiseCorners, 1)), &s); err != nil {
+		t.Fatal(err)
+	}
+	for _, constraint := range s.GetConstraints() {
+		if satisfied, err := constraint.IsSatisfied(&s); err != nil || !satisfied {
+			t.Errorf("constraint %d is not satisfied after loading: %v", constraint.GetId(), err)
+		}
+	}
+
+	before := map[int]SketchPoint{}
+	for _, element := range s.Elements {
+		if point, ok := element.(*SketchPoint); ok {
+			before[point.Id] = *point
+		}
+	}
+	if _, err := s.AttemptApplyConstraints(); err != nil {
+		t.Fatal(err)
+	}
+	for id, point := range before {
+		moved, err := GetElementByID[*SketchPoint](&s, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if moved.Position.DistanceTo(point.Position) > 1e-9 {
+			t.Errorf("point %d moved from %v to %v", id, point.Position, moved.Position)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package sketch
+
+import (
+	"math"
+)
+
+const pivotEpsilon = 1e-12
+
+func newMatrix(rows, cols int) [][]float64 {
+	m := make([][]float64, rows)
+	for i := range m {
+		m[i] = make([]float64, cols)
+	}
+	return m
+}
+
+func cloneMatrix(m [][]float64) [][]float64 {
+	c := make([][]float64, len(m))
+	for i, row := range m {
+		c[i] = append([]float64(nil), row...)
+	}
+	return c
+}
+
+// returns JᵀJ and Jᵀr for the normal equations of a least squares step
+func normalEquations(jacobian [][]float64, residuals []float64, cols int) ([][]float64, []float64) {
+	jtj := newMatrix(cols, cols)
+	jtr := make([]float64, cols)
+	for row, r := range jacobian {
+		for i := 0; i < cols; i++ {
+			if r[i] == 0 {
+				continue
+			}
+			jtr[i] += r[i] * residuals[row]
+			for j := 0; j < cols; j++ {
+				jtj[i][j] += r[i] * r[j]
+			}
+		}
+	}
+	return jtj, jtr
+}
+
+// solves a*x = b with gaussian elimination and partial pivoting, a and b are not modified
+func solveLinearSystem(a [][]float64, b []float64) ([]float64, bool) {
+	n := len(b)
+	m := cloneMatrix(a)
+	x := append([]float64(nil), b...)
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(m[pivot][col]) < pivotEpsilon {
+			return nil, false
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		x[col], x[pivot] = x[pivot], x[col]
+
+		for row := col + 1; row < n; row++ {
+			factor := m[row][col] / m[col][col]
+			if factor == 0 {
+				continue
+			}
+			for k := col; k < n; k++ {
+				m[row][k] -= factor * m[col][k]
+			}
+			x[row] -= factor * x[col]
+		}
+	}
+
+	// back substitution
+	for row := n - 1; row >= 0; row-- {
+		sum := x[row]
+		for k := row + 1; k < n; k++ {
+			sum -= m[row][k] * x[k]
+		}
+		x[row] = sum / m[row][row]
+	}
+	return x, true
+}
+
+// reduces m to reduced row echelon form in place and returns the pivot column of each non-zero row
+func reducedRowEchelon(m [][]float64, tolerance float64) []int {
+	pivots := make([]int, 0)
+	if len(m) == 0 {
+		return pivots
+	}
+	rows, cols := len(m), len(m[0])
+
+	row := 0
+	for col := 0; col < cols && row < rows; col++ {
+		pivot := row
+		for r := row + 1; r < rows; r++ {
+			if math.Abs(m[r][col]) > math.Abs(m[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(m[pivot][col]) <= tolerance {
+			for r := row; r < rows; r++ {
+				m[r][col] = 0
+			}
+			continue
+		}
+		m[row], m[pivot] = m[pivot], m[row]
+
+		scale := m[row][col]
+		for k := col; k < cols; k++ {
+			m[row][k] /= scale
+		}
+		for r := 0; r < rows; r++ {
+			if r == row || m[r][col] == 0 {
+				continue
+			}
+			factor := m[r][col]
+			for k := col; k < cols; k++ {
+				m[r][k] -= factor * m[row][k]
+			}
+		}
+
+		pivots = append(pivots, col)
+		row++
+	}
+	return pivots
+}
+
+// returns a basis of the null space of m, one vector per free column
+func nullSpace(m [][]float64, cols int, tolerance float64) [][]float64 {
+	reduced := cloneMatrix(m)
+	pivots := reducedRowEchelon(reduced, tolerance)
+
+	isPivot := make([]bool, cols)
+	for _, col := range pivots {
+		isPivot[col] = true
+	}
+
+	basis := make([][]float64, 0)
+	for free := 0; free < cols; free++ {
+		if isPivot[free] {
+			continue
+		}
+		v := make([]float64, cols)
+		v[free] = 1
+		for row, col := range pivots {
+			v[col] = -reduced[row][free]
+		}
+		basis = append(basis, v)
+	}
+	return basis
+}
+
+func matrixRank(m [][]float64, tolerance float64) int {
+	return len(reducedRowEchelon(cloneMatrix(m), tolerance))
+}
+
+// rowBasis incrementally keeps an orthonormal basis of the rows added to it
+type rowBasis struct {
+	rows      [][]float64
+	tolerance float64
+}
+
+// add returns false when the row is a linear combination of the rows added before
+func (b *rowBasis) add(row []float64) bool {
+	v := append([]float64(nil), row...)
+	for _, basisRow := range b.rows {
+		projection := 0.0
+		for i := range v {
+			projection += v[i] * basisRow[i]
+		}
+		for i := range v {
+			v[i] -= projection * basisRow[i]
+		}
+	}
+
+	norm := math.Sqrt(sumOfSquares(v))
+	if norm <= b.tolerance*math.Max(1, math.Sqrt(sumOfSquares(row))) {
+		return false
+	}
+	for i := range v {
+		v[i] /= norm
+	}
+	b.rows = append(b.rows, v)
+	return true
+}
@@ -0,0 +1,146 @@
+package sketch
+
+import (
+	"math"
+
+	"unholy-cad/geom"
+)
+
+// SectionProperties of a region with its holes subtracted. Like every position in the
+// sketch they are measured with y pointing down, which flips the sign of Ixy compared to
+// a drawing with y pointing up
+type SectionProperties struct {
+	Area float64 `json:"area"`
+	// length of the outer boundary and of the holes
+	Perimeter float64   `json:"perimeter"`
+	Centroid  geom.Vec2 `json:"centroid"`
+	// second moments of area about the axes through the centroid parallel to x and y
+	Ixx float64 `json:"ixx"`
+	Iyy float64 `json:"iyy"`
+	Ixy float64 `json:"ixy"`
+	// polar moment about the centroid, Ixx + Iyy
+	J float64 `json:"j"`
+}
+
+// moments are the integrals of 1, x, y, x², y² and xy over an area, about the origin
+type moments struct {
+	a, x, y, xx, yy, xy float64
+}
+
+func (m moments) add(other moments) moments {
+	return moments{
+		a:  m.a + other.a,
+		x:  m.x + other.x,
+		y:  m.y + other.y,
+		xx: m.xx + other.xx,
+		yy: m.yy + other.yy,
+		xy: m.xy + other.xy,
+	}
+}
+
+func (m moments) sub(other moments) moments {
+	return m.add(moments{a: -other.a, x: -other.x, y: -other.y, xx: -other.xx, yy: -other.yy, xy: -other.xy})
+}
+
+// lineMoments is the share of a straight boundary edge in the moments of the area it
+// encloses, from Green's theorem
+func lineMoments(p0, p1 geom.Vec2) moments {
+	c := p0.Cross(p1)
+	return moments{
+		a:  c / 2,
+		x:  (p0.X + p1.X) * c / 6,
+		y:  (p0.Y + p1.Y) * c / 6,
+		xx: (p0.X*p0.X + p0.X*p1.X + p1.X*p1.X) * c / 12,
+		yy: (p0.Y*p0.Y + p0.Y*p1.Y + p1.Y*p1.Y) * c / 12,
+		xy: (p0.X*p1.Y + 2*p0.X*p0.Y + 2*p1.X*p1.Y + p1.X*p0.Y) * c / 24,
+	}
+}
+
+// sectorMoments are the moments of a circular sector, negative when the sweep is
+func sectorMoments(center geom.Vec2, radius, startAngle, sweep float64) moments {
+	endAngle := startAngle + sweep
+	r2 := radius * radius
+	r3 := r2 * radius
+	r4 := r2 * r2
+
+	// about the center first
+	area := r2 * sweep / 2
+	u := r3 / 3 * (math.Sin(endAngle) - math.Sin(startAngle))
+	v := r3 / 3 * (math.Cos(startAngle) - math.Cos(endAngle))
+	uu := r4 / 4 * (sweep/2 + (math.Sin(2*endAngle)-math.Sin(2*startAngle))/4)
+	vv := r4 / 4 * (sweep/2 - (math.Sin(2*endAngle)-math.Sin(2*startAngle))/4)
+	uv := r4 / 8 * (math.Pow(math.Sin(endAngle), 2) - math.Pow(math.Sin(startAngle), 2))
+
+	cx, cy := center.X, center.Y
+	return moments{
+		a:  area,
+		x:  u + cx*area,
+		y:  v + cy*area,
+		xx: uu + 2*cx*u + cx*cx*area,
+		yy: vv + 2*cy*v + cy*cy*area,
+		xy: uv + cx*v + cy*u + cx*cy*area,
+	}
+}
+
+// moments returns the share of the segment in the moments of the loop it is part of. An
+// arc is the sector around its center without the two radii
+func (seg segment) moments() moments {
+	if !seg.arc {
+		return lineMoments(seg.from, seg.to)
+	}
+	start := seg.from.Sub(seg.center)
+	sector := sectorMoments(seg.center, seg.radius, math.Atan2(start.Y, start.X), seg.sweep)
+	return sector.sub(lineMoments(seg.center, seg.from)).sub(lineMoments(seg.to, seg.center))
+}
+
+func (seg segment) length() float64 {
+	if seg.arc {
+		return seg.radius * math.Abs(seg.sweep)
+	}
+	return seg.from.DistanceTo(seg.to)
+}
+
+// Measure computes the section properties of the region. Arcs and circles are measured
+// exactly, not as polygons
+func (r Region) Measure(s *Sketch) (SectionProperties, error) {
+	total := moments{}
+	perimeter := 0.0
+	for _, loop := range append([]Loop{r.Outer}, r.Holes...) {
+		segments, err := loop.segments(s)
+		if err != nil {
+			return SectionProperties{}, err
+		}
+		for _, seg := range segments {
+			total = total.add(seg.moments())
+			perimeter += seg.length()
+		}
+	}
+
+	properties := SectionProperties{Area: total.a, Perimeter: perimeter}
+	if total.a == 0 {
+		return properties, nil
+	}
+	c := geom.Vec2{X: total.x / total.a, Y: total.y / total.a}
+	properties.Centroid = c
+	// parallel axis theorem to move from the origin to the centroid
+	properties.Ixx = total.yy - total.a*c.Y*c.Y
+	properties.Iyy = total.xx - total.a*c.X*c.X
+	properties.Ixy = total.xy - total.a*c.X*c.Y
+	properties.J = properties.Ixx + properties.Iyy
+	return properties, nil
+}
+
+// Contains reports whether a position is inside the region and not in one of its holes
+func (r Region) Contains(s *Sketch, p geom.Vec2) (bool, error) {
+	for i, loop := range append([]Loop{r.Outer}, r.Holes...) {
+		polygon, err := loop.Points(s)
+		if err != nil {
+			return false, err
+		}
+		// inside the outer boundary, outside every hole
+		if contains(polygon, p) != (i == 0) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,141 @@
+package sketch
+
+import (
+	"math"
+	"testing"
+
+	"unholy-cad/geom"
+)
+
+func TestMeasure(t *testing.T) {
+	// rectangle 40 by 20 with a hole of radius 5 at (10, 10)
+	holeArea := 800 - 25*math.Pi
+	holeX := (800*20 - 25*math.Pi*10) / holeArea
+	semicircleY := 4 * 3 / (3 * math.Pi)
+
+	tests := []struct {
+		name   string
+		sketch func() *Sketch
+		want   SectionProperties
+	}{
+		{
+			name: "rectangle",
+			sketch: func() *Sketch {
+				s := NewSketch()
+				addRectangle(s, 10, 5, 50, 25)
+				return s
+			},
+			want: SectionProperties{
+				Area:      800,
+				Perimeter: 120,
+				Centroid:  geom.Vec2{X: 30, Y: 15},
+				// b h³ / 12 and h b³ / 12
+				Ixx: 40 * 20 * 20 * 20 / 12.0,
+				Iyy: 20 * 40 * 40 * 40 / 12.0,
+				J:   40*20*20*20/12.0 + 20*40*40*40/12.0,
+			},
+		},
+		{
+			name: "circle",
+			sketch: func() *Sketch {
+				s := NewSketch()
+				s.AddCircle(s.AddPoint(geom.Vec2{X: 3, Y: 4}).Id, 5)
+				return s
+			},
+			want: SectionProperties{
+				Area:      25 * math.Pi,
+				Perimeter: 10 * math.Pi,
+				Centroid:  geom.Vec2{X: 3, Y: 4},
+				// π r⁴ / 4 about either axis
+				Ixx: 625 * math.Pi / 4,
+				Iyy: 625 * math.Pi / 4,
+				J:   625 * math.Pi / 2,
+			},
+		},
+		{
+			name: "rectangle with a hole",
+			sketch: func() *Sketch {
+				s := NewSketch()
+				addRectangle(s, 0, 0, 40, 20)
+				s.AddCircle(s.AddPoint(geom.Vec2{X: 10, Y: 10}).Id, 5)
+				return s
+			},
+			want: SectionProperties{
+				Area:      holeArea,
+				Perimeter: 120 + 10*math.Pi,
+				Centroid:  geom.Vec2{X: holeX, Y: 10},
+				// both share the axis through y = 10, the parallel axis theorem moves them to x
+				Ixx: 40*20*20*20/12.0 - 625*math.Pi/4,
+				Iyy: 20*40*40*40/12.0 + 800*(20-holeX)*(20-holeX) - (625*math.Pi/4 + 25*math.Pi*(10-holeX)*(10-holeX)),
+				J: 40*20*20*20/12.0 - 625*math.Pi/4 +
+					20*40*40*40/12.0 + 800*(20-holeX)*(20-holeX) - (625*math.Pi/4 + 25*math.Pi*(10-holeX)*(10-holeX)),
+			},
+		},
+		{
+			// b² h² / 72, negative with the right angle at the origin
+			name: "right triangle",
+			sketch: func() *Sketch {
+				s := NewSketch()
+				a, b, c := s.AddPoint(geom.Vec2{X: 0, Y: 0}), s.AddPoint(geom.Vec2{X: 6, Y: 0}), s.AddPoint(geom.Vec2{X: 0, Y: 12})
+				s.AddLine(a.Id, b.Id)
+				s.AddLine(b.Id, c.Id)
+				s.AddLine(c.Id, a.Id)
+				return s
+			},
+			want: SectionProperties{
+				Area:      36,
+				Perimeter: 18 + math.Sqrt(180),
+				Centroid:  geom.Vec2{X: 2, Y: 4},
+				Ixx:       6 * 12 * 12 * 12 / 36.0,
+				Iyy:       12 * 6 * 6 * 6 / 36.0,
+				Ixy:       -6 * 6 * 12 * 12 / 72.0,
+				J:         6*12*12*12/36.0 + 12*6*6*6/36.0,
+			},
+		},
+		{
+			// a half circle of radius 3 below its diameter, measured along the arc
+			name: "half circle",
+			sketch: func() *Sketch {
+				s := NewSketch()
+				center := s.AddPoint(geom.Vec2{X: 0, Y: 0})
+				a, b := s.AddPoint(geom.Vec2{X: 3, Y: 0}), s.AddPoint(geom.Vec2{X: -3, Y: 0})
+				s.AddArc(center.Id, a.Id, b.Id)
+				s.AddLine(b.Id, a.Id)
+				return s
+			},
+			want: SectionProperties{
+				Area:      9 * math.Pi / 2,
+				Perimeter: 3*math.Pi + 6,
+				Centroid:  geom.Vec2{X: 0, Y: semicircleY},
+				Ixx:       (math.Pi/8 - 8/(9*math.Pi)) * 81,
+				Iyy:       math.Pi / 8 * 81,
+				J:         (math.Pi/8-8/(9*math.Pi))*81 + math.Pi/8*81,
+			},
+		},
+	}
+	near := func(got, want float64) bool {
+		return math.Abs(got-want) <= 1e-9*math.Max(1, math.Abs(want))
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := test.sketch()
+			topology, err := s.AnalyzeTopology()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(topology.Regions) != 1 {
+				t.Fatalf("got %d regions, want 1", len(topology.Regions))
+			}
+			got, err := topology.Regions[0].Measure(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := test.want
+			if !near(got.Area, want.Area) || !near(got.Perimeter, want.Perimeter) ||
+				!near(got.Centroid.X, want.Centroid.X) || !near(got.Centroid.Y, want.Centroid.Y) ||
+				!near(got.Ixx, want.Ixx) || !near(got.Iyy, want.Iyy) || !near(got.Ixy, want.Ixy) || !near(got.J, want.J) {
+				t.Errorf("got %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
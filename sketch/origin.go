@@ -0,0 +1,139 @@
+package sketch
+
+import (
+	"unholy-cad/geom"
+)
+
+// ids of the built-in origin and axis elements. They are part of every sketch, can be
+// referenced by constraints like any other element, but never move and cannot be deleted
+const (
+	OriginId   = -1
+	XAxisEndId = -2
+	YAxisEndId = -3
+	XAxisId    = -4
+	YAxisId    = -5
+)
+
+// IsBuiltin reports whether an id belongs to the origin or one of the axes
+func IsBuiltin(id int) bool {
+	return id <= OriginId && id >= YAxisId
+}
+
+func builtinElements() []SketchElement {
+	return []SketchElement{
+		&SketchPoint{Id: OriginId, Position: geom.Vec2{X: 0, Y: 0}},
+		&SketchPoint{Id: XAxisEndId, Position: geom.Vec2{X: 1, Y: 0}},
+		&SketchPoint{Id: YAxisEndId, Position: geom.Vec2{X: 0, Y: 1}},
+		&SketchLine{Id: XAxisId, StartId: OriginId, EndId: XAxisEndId},
+		&SketchLine{Id: YAxisId, StartId: OriginId, EndId: YAxisEndId},
+	}
+}
+
+// NewSketch returns an empty sketch with the origin and axes
+func NewSketch() *Sketch {
+	return &Sketch{Elements: builtinElements()}
+}
+
+// EnsureOrigin puts back any missing origin or axis element, in front of the other elements
+func (s *Sketch) EnsureOrigin() {
+	present := map[int]bool{}
+	for _, element := range s.Elements {
+		present[element.GetId()] = true
+	}
+
+	missing := make([]SketchElement, 0)
+	for _, element := range builtinElements() {
+		if !present[element.GetId()] {
+			missing = append(missing, element)
+		}
+	}
+	s.Elements = append(missing, s.Elements...)
+}
+
+// getAnchoredPoints returns the points constraint branches are not allowed to move: the
+// origin points and the points held by a fixed constraint
+func getAnchoredPoints(constraints []SketchConstraint) map[int]bool {
+	anchored := map[int]bool{OriginId: true, XAxisEndId: true, YAxisEndId: true}
+	for _, constraint := range constraints {
+		if fixed, ok := constraint.(*SketchConstraintFixed); ok {
+			anchored[fixed.PointId] = true
+		}
+	}
+	return anchored
+}
+
+// movedAnyPoint reports whether one of the points has a different position than in before
+func (s *Sketch) movedAnyPoint(before []SketchElement, ids map[int]bool) bool {
+	previous := &Sketch{Elements: before}
+	for id := range ids {
+		point, err := GetElementByID[*SketchPoint](s, id)
+		if err != nil {
+			continue
+		}
+		previousPoint, err := GetElementByID[*SketchPoint](previous, id)
+		if err != nil {
+			continue
+		}
+		if point.Position != previousPoint.Position {
+			return true
+		}
+	}
+	return false
+}
+
+// SketchConstraintFixed locks a point to absolute coordinates
+type SketchConstraintFixed struct {
+	Id       int       `json:"id"`
+	PointId  int       `json:"pointId"`
+	Position geom.Vec2 `json:"position"`
+}
+
+func (c *SketchConstraintFixed) Clone() SketchElement {
+	return &SketchConstraintFixed{
+		Id:       c.Id,
+		PointId:  c.PointId,
+		Position: c.Position.Clone(),
+	}
+}
+
+func (c *SketchConstraintFixed) GetId() int {
+	return c.Id
+}
+
+func (c *SketchConstraintFixed) GetReferences() []int {
+	return []int{c.PointId}
+}
+
+func (c *SketchConstraintFixed) GetDependencies(s *Sketch) ([]int, error) {
+	return []int{c.PointId}, nil
+}
+
+func (c *SketchConstraintFixed) GetResiduals(s *Sketch) ([]float64, error) {
+	point, err := GetElementByID[*SketchPoint](s, c.PointId)
+	if err != nil {
+		return nil, err
+	}
+	offset := point.Position.Sub(c.Position)
+	return []float64{offset.X, offset.Y}, nil
+}
+
+func (c *SketchConstraintFixed) IsSatisfied(s *Sketch) (bool, error) {
+	point, err := GetElementByID[*SketchPoint](s, c.PointId)
+	if err != nil {
+		return false, err
+	}
+	return isNearZero(point.Position.DistanceTo(c.Position)), nil
+}
+
+func (c *SketchConstraintFixed) GetBranches() int {
+	return 1
+}
+
+func (c *SketchConstraintFixed) Apply(s *Sketch, branch int) (bool, error) {
+	point, err := GetElementByID[*SketchPoint](s, c.PointId)
+	if err != nil {
+		return false, err
+	}
+	point.Position = c.Position
+	return true, nil
+}
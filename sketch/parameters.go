@@ -0,0 +1,449 @@
+package sketch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Parameter is a named value that dimensions and other parameters can refer to by name
+type Parameter struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	// result of the last evaluation
+	Value float64 `json:"-"`
+}
+
+// dimension is embedded in the constraints that hold a value, so the value can be
+// driven by an expression and referred to by name
+type dimension struct {
+	// optional name, dimensions are called d<id> otherwise
+	Name string `json:"name,omitempty"`
+	// expression the value is computed from, the value is a plain number when empty
+	Expression string `json:"expression,omitempty"`
+	// reference dimensions only display the measured value, the solver ignores them
+	Reference bool `json:"reference,omitempty"`
+}
+
+// isReservedName is true for names of the form d<number>, which dimensions without a name
+// are called. Only the dimension with that id may use one
+func isReservedName(name string) bool {
+	if len(name) < 2 || name[0] != 'd' {
+		return false
+	}
+	for _, c := range name[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *dimension) getName(id int) string {
+	if d.Name != "" {
+		return d.Name
+	}
+	return fmt.Sprintf("d%d", id)
+}
+
+func (d *dimension) GetExpression() string {
+	return d.Expression
+}
+
+func (d *dimension) SetExpression(expression string) {
+	d.Expression = expression
+}
+
+func (d *dimension) IsReference() bool {
+	return d.Reference
+}
+
+func (d *dimension) SetReference(reference bool) {
+	d.Reference = reference
+}
+
+// SketchDimension is implemented by constraints with an editable value, such as lengths,
+// distances, radii and angles. Angles are in degrees
+type SketchDimension interface {
+	SketchConstraint
+	GetName() string
+	GetValue() float64
+	SetValue(value float64)
+	// GetMeasuredValue returns the value the geometry has now, in the units of GetValue
+	GetMeasuredValue(s *Sketch) (float64, error)
+	GetExpression() string
+	SetExpression(expression string)
+	IsReference() bool
+	SetReference(reference bool)
+}
+
+// isReferenceDimension is true for dimensions that are measured instead of enforced
+func isReferenceDimension(constraint SketchConstraint) bool {
+	d, ok := constraint.(SketchDimension)
+	return ok && d.IsReference()
+}
+
+// isAngleDimension is true for dimensions measured in degrees, the others are lengths
+func isAngleDimension(d SketchDimension) bool {
+	switch d.(type) {
+	case *SketchConstraintCornerAngle, *SketchConstraintLineAngle:
+		return true
+	}
+	return false
+}
+
+func dimensionQuantity(d SketchDimension) quantity {
+	if isAngleDimension(d) {
+		return quantityAngle
+	}
+	return quantityLength
+}
+
+// checkDimensionQuantity fails when an expression gives a dimension a value of the wrong
+// kind, like degrees for a length. Plain numbers fit every dimension
+func checkDimensionQuantity(d SketchDimension, q quantity) error {
+	if want := dimensionQuantity(d); q != quantityNone && q != want {
+		return fmt.Errorf("%w: %s instead of %s", ErrUnitMismatch, q, want)
+	}
+	return nil
+}
+
+// checkDimensionValue fails for lengths, distances, radii and diameters that are not positive
+func checkDimensionValue(d SketchDimension, value float64) error {
+	if !isAngleDimension(d) && !(value > 0) {
+		return fmt.Errorf("%w: %g", ErrNotPositive, value)
+	}
+	return nil
+}
+
+func (s *Sketch) GetDimensions() []SketchDimension {
+	dimensions := make([]SketchDimension, 0)
+	for _, element := range s.Elements {
+		if d, ok := element.(SketchDimension); ok {
+			dimensions = append(dimensions, d)
+		}
+	}
+	return dimensions
+}
+
+// GetDimensionByName returns the dimension with the given name, or nil
+func (s *Sketch) GetDimensionByName(name string) SketchDimension {
+	for _, d := range s.GetDimensions() {
+		if d.GetName() == name {
+			return d
+		}
+	}
+	return nil
+}
+
+// GetParameter returns the parameter with the given name, or nil
+func (s *Sketch) GetParameter(name string) *Parameter {
+	for _, p := range s.Parameters {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Sketch) cloneParameters() []*Parameter {
+	if s.Parameters == nil {
+		return nil
+	}
+	parameters := make([]*Parameter, len(s.Parameters))
+	for i, p := range s.Parameters {
+		clone := *p
+		parameters[i] = &clone
+	}
+	return parameters
+}
+
+// namedValue is a parameter or dimension during evaluation
+type namedValue struct {
+	name string
+	// nil for dimensions that hold a plain number
+	expression expression
+	value      float64
+	set        func(float64)
+	// dimension the value belongs to, nil for parameters
+	dimension SketchDimension
+}
+
+// EvaluateParameters computes every parameter and every dimension with an expression, in
+// dependency order. Nothing is changed when a name is unknown, duplicated or part of a cycle,
+// or when an expression gives a dimension a unit or value that does not fit it, also through
+// the parameters it uses
+func (s *Sketch) EvaluateParameters() error {
+	values := map[string]*namedValue{}
+	order := make([]string, 0)
+	add := func(name, source string, value float64, set func(float64), d SketchDimension) error {
+		if !isIdentifier(name) {
+			return &ParameterError{Name: name, Err: ErrInvalidName}
+		}
+		if isReservedName(name) && (d == nil || name != fmt.Sprintf("d%d", d.GetId())) {
+			return &ParameterError{Name: name, Err: ErrReservedName}
+		}
+		if values[name] != nil {
+			return &ParameterError{Name: name, Err: ErrDuplicateName}
+		}
+		nv := &namedValue{name: name, value: value, set: set, dimension: d}
+		if strings.TrimSpace(source) != "" {
+			parsed, err := parseExpression(source)
+			if err != nil {
+				return &ParameterError{Name: name, Err: err}
+			}
+			nv.expression = parsed
+		}
+		values[name] = nv
+		order = append(order, name)
+		return nil
+	}
+
+	for _, p := range s.Parameters {
+		p := p
+		if strings.TrimSpace(p.Expression) == "" {
+			return &ParameterError{Name: p.Name, Err: fmt.Errorf("%w: empty", ErrInvalidExpression)}
+		}
+		if err := add(p.Name, p.Expression, 0, func(v float64) { p.Value = v }, nil); err != nil {
+			return err
+		}
+	}
+	for _, d := range s.GetDimensions() {
+		if !d.IsReference() {
+			if err := add(d.GetName(), d.GetExpression(), d.GetValue(), d.SetValue, d); err != nil {
+				return err
+			}
+			continue
+		}
+		// a reference dimension can be used in expressions with the value measured now
+		if strings.TrimSpace(d.GetExpression()) != "" {
+			return &ParameterError{Name: d.GetName(), Err: ErrReferenceExpression}
+		}
+		measured, err := d.GetMeasuredValue(s)
+		if err != nil {
+			measured = d.GetValue()
+		}
+		if err := add(d.GetName(), "", measured, d.SetValue, d); err != nil {
+			return err
+		}
+	}
+
+	// depth first topological sort, a name that is reached again while it is still
+	// being visited closes a cycle
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	sorted := make([]*namedValue, 0, len(values))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			start := 0
+			for i, n := range path {
+				if n == name {
+					start = i
+				}
+			}
+			cycle := append(append([]string{}, path[start:]...), name)
+			return &ParameterError{Name: name, Err: fmt.Errorf("%w: %s", ErrCycle, strings.Join(cycle, " -> "))}
+		}
+
+		state[name] = visiting
+		nv := values[name]
+		if nv.expression != nil {
+			names := map[string]bool{}
+			nv.expression.collectNames(names)
+			dependencies := make([]string, 0, len(names))
+			for dependency := range names {
+				dependencies = append(dependencies, dependency)
+			}
+			sort.Strings(dependencies)
+
+			for _, dependency := range dependencies {
+				if values[dependency] == nil {
+					return &ParameterError{Name: name, Err: fmt.Errorf("%w: %s", ErrUnknownName, dependency)}
+				}
+				if err := visit(dependency, append(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+		state[name] = visited
+		sorted = append(sorted, nv)
+		return nil
+	}
+	for _, name := range order {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+
+	// parameters carry the units of their expressions to where they are used, dimensions
+	// are lengths or angles whatever their expression is
+	results := map[string]float64{}
+	quantities := map[string]quantity{}
+	for _, nv := range sorted {
+		value := nv.value
+		if nv.expression != nil {
+			var err error
+			value, err = nv.expression.evaluate(results)
+			if err != nil {
+				return &ParameterError{Name: nv.name, Err: err}
+			}
+			quantities[nv.name] = nv.expression.quantity(quantities)
+			if nv.dimension != nil {
+				if err := checkDimensionQuantity(nv.dimension, quantities[nv.name]); err != nil {
+					return &ParameterError{Name: nv.name, Err: err}
+				}
+				if err := checkDimensionValue(nv.dimension, value); err != nil {
+					return &ParameterError{Name: nv.name, Err: err}
+				}
+			}
+		}
+		if nv.dimension != nil {
+			quantities[nv.name] = dimensionQuantity(nv.dimension)
+		}
+		results[nv.name] = value
+	}
+
+	// only write back once everything evaluated
+	for _, nv := range sorted {
+		nv.set(results[nv.name])
+	}
+	return nil
+}
+
+// SetParameter adds a parameter or changes its expression and evaluates all expressions.
+// The sketch is left unchanged on error
+func (s *Sketch) SetParameter(name, expression string) error {
+	backup := s.cloneParameters()
+	if p := s.GetParameter(name); p != nil {
+		p.Expression = expression
+	} else {
+		s.Parameters = append(s.Parameters, &Parameter{Name: name, Expression: expression})
+	}
+
+	if err := s.EvaluateParameters(); err != nil {
+		s.Parameters = backup
+		return err
+	}
+	return nil
+}
+
+// RemoveParameter deletes a parameter, failing when an expression still refers to it
+func (s *Sketch) RemoveParameter(name string) error {
+	backup := s.cloneParameters()
+	parameters := make([]*Parameter, 0, len(s.Parameters))
+	for _, p := range s.Parameters {
+		if p.Name != name {
+			parameters = append(parameters, p)
+		}
+	}
+	s.Parameters = parameters
+
+	if err := s.EvaluateParameters(); err != nil {
+		s.Parameters = backup
+		return err
+	}
+	return nil
+}
+
+// SetDimensionExpression drives a dimension by an expression and evaluates all expressions.
+// The sketch is left unchanged on error
+func (s *Sketch) SetDimensionExpression(id int, expression string) error {
+	d, err := GetElementByID[SketchDimension](s, id)
+	if err != nil {
+		return err
+	}
+
+	previousExpression, previousValue := d.GetExpression(), d.GetValue()
+	d.SetExpression(expression)
+	if err := s.EvaluateParameters(); err != nil {
+		d.SetExpression(previousExpression)
+		d.SetValue(previousValue)
+		return err
+	}
+	return nil
+}
+
+// SetDimensionReference switches a dimension between driving the geometry and only
+// measuring it. Either way the value becomes the one measured now, so nothing moves, and a
+// reference dimension drops its expression. The sketch is left unchanged on error
+func (s *Sketch) SetDimensionReference(id int, reference bool) error {
+	d, err := GetElementByID[SketchDimension](s, id)
+	if err != nil {
+		return err
+	}
+	if d.IsReference() == reference {
+		return nil
+	}
+	measured, err := d.GetMeasuredValue(s)
+	if err != nil {
+		return err
+	}
+	if err := checkDimensionValue(d, measured); err != nil {
+		return &ParameterError{Name: d.GetName(), Err: err}
+	}
+
+	previousExpression, previousValue := d.GetExpression(), d.GetValue()
+	if reference {
+		d.SetExpression("")
+	}
+	d.SetReference(reference)
+	d.SetValue(measured)
+	if err := s.EvaluateParameters(); err != nil {
+		d.SetReference(!reference)
+		d.SetExpression(previousExpression)
+		d.SetValue(previousValue)
+		return err
+	}
+	return nil
+}
+
+// EditDimension sets a dimension from text typed by the user, such as "25.4", "1in" or
+// "width/2". Text without names sets a plain value, text with names is kept as the
+// expression. The sketch is left unchanged on error
+func (s *Sketch) EditDimension(id int, source string) error {
+	d, err := GetElementByID[SketchDimension](s, id)
+	if err != nil {
+		return err
+	}
+	if d.IsReference() {
+		return &ParameterError{Name: d.GetName(), Err: ErrReferenceExpression}
+	}
+	parsed, err := parseExpression(source)
+	if err != nil {
+		return &ParameterError{Name: d.GetName(), Err: err}
+	}
+	names := map[string]bool{}
+	parsed.collectNames(names)
+	if len(names) > 0 {
+		return s.SetDimensionExpression(id, source)
+	}
+
+	value, err := parsed.evaluate(nil)
+	if err == nil {
+		err = checkDimensionQuantity(d, parsed.quantity(nil))
+	}
+	if err == nil {
+		err = checkDimensionValue(d, value)
+	}
+	if err != nil {
+		return &ParameterError{Name: d.GetName(), Err: err}
+	}
+	previousExpression, previousValue := d.GetExpression(), d.GetValue()
+	d.SetExpression("")
+	d.SetValue(value)
+	// dimensions driven by this one follow the new value
+	if err := s.EvaluateParameters(); err != nil {
+		d.SetExpression(previousExpression)
+		d.SetValue(previousValue)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package sketch
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"unholy-cad/geom"
+)
+
+func TestSetDimensionReference(t *testing.T) {
+	s := NewSketch()
+	corner := s.AddPoint(geom.Vec2{X: 0, Y: 0})
+	end1 := s.AddPoint(geom.Vec2{X: 10, Y: 0})
+	end2 := s.AddPoint(geom.Vec2{X: 0, Y: 10})
+	s.AddLine(corner.Id, end1.Id)
+	s.AddLine(corner.Id, end2.Id)
+	circle := s.AddCircle(corner.Id, 4)
+	radius := &SketchConstraintRadius{Id: s.NextId(), CurveId: circle.Id, Radius: 4}
+	s.Elements = append(s.Elements, radius)
+	angle := &SketchConstraintCornerAngle{Id: s.NextId(), CornerPointId: corner.Id, LinePoint1Id: end1.Id, LinePoint2Id: end2.Id, Angle: 90}
+	s.Elements = append(s.Elements, angle)
+	if err := s.SetParameter("r", "4"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetDimensionExpression(radius.Id, "r"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range []SketchDimension{radius, angle} {
+		if err := s.SetDimensionReference(d.GetId(), true); err != nil {
+			t.Fatalf("%s: %v", d.GetName(), err)
+		}
+		if !d.IsReference() || d.GetExpression() != "" {
+			t.Errorf("%s: reference %v, expression %q", d.GetName(), d.IsReference(), d.GetExpression())
+		}
+	}
+
+	// the geometry changed while the dimensions only measured it
+	circle.Radius = 5
+	for _, d := range []SketchDimension{radius, angle} {
+		if err := s.SetDimensionReference(d.GetId(), false); err != nil {
+			t.Fatalf("%s: %v", d.GetName(), err)
+		}
+		measured, err := d.GetMeasuredValue(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.IsReference() || math.Abs(d.GetValue()-measured) > 1e-9 {
+			t.Errorf("%s: reference %v, value %v, measured %v", d.GetName(), d.IsReference(), d.GetValue(), measured)
+		}
+	}
+	if radius.Radius != 5 {
+		t.Errorf("radius %v, want the measured 5", radius.Radius)
+	}
+
+	// a dimension that measures zero cannot drive a length
+	if err := s.SetDimensionReference(radius.Id, true); err != nil {
+		t.Fatal(err)
+	}
+	circle.Radius = 0
+	if err := s.SetDimensionReference(radius.Id, false); !errors.Is(err, ErrNotPositive) {
+		t.Errorf("got %v, want %v", err, ErrNotPositive)
+	}
+	if !radius.IsReference() {
+		t.Error("failed switch changed the dimension")
+	}
+}
+
+// dimensionedSketch has a line with a length and a right angle corner
+func dimensionedSketch() (*Sketch, *SketchConstraintLineLength, *SketchConstraintCornerAngle) {
+	s := NewSketch()
+	corner := s.AddPoint(geom.Vec2{X: 0, Y: 0})
+	end1 := s.AddPoint(geom.Vec2{X: 10, Y: 0})
+	end2 := s.AddPoint(geom.Vec2{X: 0, Y: 10})
+	line := s.AddLine(corner.Id, end1.Id)
+	s.AddLine(corner.Id, end2.Id)
+	length := &SketchConstraintLineLength{Id: s.NextId(), LineId: line.Id, Length: 10}
+	s.Elements = append(s.Elements, length)
+	angle := &SketchConstraintCornerAngle{Id: s.NextId(), CornerPointId: corner.Id, LinePoint1Id: end1.Id, LinePoint2Id: end2.Id, Angle: 90}
+	s.Elements = append(s.Elements, angle)
+	return s, length, angle
+}
+
+func TestEvaluateParameters(t *testing.T) {
+	tests := []struct {
+		name       string
+		parameters []*Parameter
+		// expressions of the length and the angle, empty for plain values
+		length, angle string
+		// name of the length, d5 when empty
+		lengthName string
+		want       error
+		wantLength float64
+		wantAngle  float64
+	}{
+		{name: "plain", wantLength: 10, wantAngle: 90},
+		{name: "parameters", parameters: []*Parameter{{Name: "w", Expression: "2 * h"}, {Name: "h", Expression: "6"}}, length: "w", angle: "h * 10", wantLength: 12, wantAngle: 60},
+		{name: "dimension names", length: "2cm", angle: "90 * d5 / 40mm", wantLength: 20, wantAngle: 45},
+		{name: "units", length: "2cm", angle: "1rad", wantLength: 20, wantAngle: 180 / math.Pi},
+		{name: "length in degrees", length: "10deg", want: ErrUnitMismatch},
+		{name: "angle in millimeters", angle: "90mm", want: ErrUnitMismatch},
+		{name: "angle in inches", angle: "(1 + 2)in", want: ErrUnitMismatch},
+		{name: "parameter units", parameters: []*Parameter{{Name: "w", Expression: "2in"}, {Name: "a", Expression: "w / 1mm"}}, length: "w", angle: "a", wantLength: 50.8, wantAngle: 50.8},
+		{name: "parameter in degrees", parameters: []*Parameter{{Name: "a", Expression: "30deg"}}, length: "a", want: ErrUnitMismatch},
+		{name: "parameter of a parameter", parameters: []*Parameter{{Name: "w", Expression: "2in"}, {Name: "h", Expression: "w * 2"}}, angle: "h", want: ErrUnitMismatch},
+		{name: "mixed parameter", parameters: []*Parameter{{Name: "w", Expression: "1in + 10deg"}}, length: "w", want: ErrUnitMismatch},
+		{name: "unused mixed parameter", parameters: []*Parameter{{Name: "w", Expression: "1in + 10deg"}}, wantLength: 10, wantAngle: 90},
+		{name: "length as angle", angle: "d5", want: ErrUnitMismatch},
+		{name: "negative length", length: "-5", want: ErrNotPositive},
+		{name: "undefined", length: "depth * 2", want: ErrUnknownName},
+		{name: "cycle", parameters: []*Parameter{{Name: "a", Expression: "b"}, {Name: "b", Expression: "a"}}, length: "a", want: ErrCycle},
+		{name: "self", parameters: []*Parameter{{Name: "a", Expression: "a + 1"}}, want: ErrCycle},
+		{name: "dimension cycle", length: "d6", angle: "d5", want: ErrCycle},
+		{name: "duplicate", parameters: []*Parameter{{Name: "a", Expression: "1"}, {Name: "a", Expression: "2"}}, want: ErrDuplicateName},
+		{name: "invalid name", parameters: []*Parameter{{Name: "2a", Expression: "1"}}, want: ErrInvalidName},
+		{name: "names like dimensions", parameters: []*Parameter{{Name: "d", Expression: "2"}, {Name: "d5x", Expression: "d * 3"}}, length: "d5x", wantLength: 6, wantAngle: 90},
+		{name: "dimension name", parameters: []*Parameter{{Name: "d5", Expression: "1"}}, want: ErrReservedName},
+		{name: "unused dimension name", parameters: []*Parameter{{Name: "d42", Expression: "1"}}, want: ErrReservedName},
+		{name: "own dimension name", lengthName: "d5", angle: "d5 / 1mm * 3", wantLength: 10, wantAngle: 30},
+		{name: "other dimension name", lengthName: "d6", want: ErrReservedName},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, length, angle := dimensionedSketch()
+			s.Parameters = test.parameters
+			length.Expression = test.length
+			length.Name = test.lengthName
+			angle.Expression = test.angle
+
+			err := s.EvaluateParameters()
+			if test.want != nil {
+				if !errors.Is(err, test.want) {
+					t.Fatalf("got %v, want %v", err, test.want)
+				}
+				if length.Length != 10 || angle.Angle != 90 {
+					t.Errorf("failed evaluation changed the dimensions to %v and %v", length.Length, angle.Angle)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(length.Length-test.wantLength) > 1e-9 || math.Abs(angle.Angle-test.wantAngle) > 1e-9 {
+				t.Errorf("got %v and %v, want %v and %v", length.Length, angle.Angle, test.wantLength, test.wantAngle)
+			}
+		})
+	}
+}
+
+func TestEditDimension(t *testing.T) {
+	tests := []struct {
+		length, angle string
+		want          error
+	}{
+		{length: "1in"},
+		{angle: "-45"},
+		{angle: "45°"},
+		{length: "0", want: ErrNotPositive},
+		{length: "-5", want: ErrNotPositive},
+		{length: "10deg", want: ErrUnitMismatch},
+		{angle: "5mm", want: ErrUnitMismatch},
+		{length: "2 +", want: ErrInvalidExpression},
+	}
+	for _, test := range tests {
+		s, length, angle := dimensionedSketch()
+		var err error
+		if test.length != "" {
+			err = s.EditDimension(length.Id, test.length)
+		} else {
+			err = s.EditDimension(angle.Id, test.angle)
+		}
+		if !errors.Is(err, test.want) && !(test.want == nil && err == nil) {
+			t.Errorf("%q%q: got %v, want %v", test.length, test.angle, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,482 @@
+package sketch
+
+import (
+	"fmt"
+	"math"
+
+	"unholy-cad/geom"
+)
+
+type SketchElement interface {
+	GetId() int
+	Clone() SketchElement
+	// ids of the elements this element refers to directly
+	GetReferences() []int
+}
+
+type SketchLine struct {
+	Id      int `json:"id"`
+	StartId int `json:"startId"`
+	EndId   int `json:"endId"`
+}
+
+func (l *SketchLine) Clone() SketchElement {
+	return &SketchLine{
+		Id:      l.Id,
+		StartId: l.StartId,
+		EndId:   l.EndId,
+	}
+}
+
+func (l *SketchLine) GetId() int {
+	return l.Id
+}
+
+func (l *SketchLine) GetReferences() []int {
+	return []int{l.StartId, l.EndId}
+}
+
+type SketchPoint struct {
+	Id       int       `json:"id"`
+	Position geom.Vec2 `json:"position"`
+}
+
+func (p *SketchPoint) Clone() SketchElement {
+	return &SketchPoint{
+		Id:       p.Id,
+		Position: p.Position.Clone(),
+	}
+}
+
+func (p *SketchPoint) GetId() int {
+	return p.Id
+}
+
+func (p *SketchPoint) GetReferences() []int {
+	return nil
+}
+
+func (p *SketchPoint) getVariables() []*float64 {
+	return []*float64{&p.Position.X, &p.Position.Y}
+}
+
+type Sketch struct {
+	Elements   []SketchElement
+	Parameters []*Parameter
+}
+
+func GetElementByID[T SketchElement](s *Sketch, id int) (T, error) {
+	var zero T
+	for _, element := range s.Elements {
+		if element.GetId() == id {
+			if specificElement, ok := element.(T); ok {
+				return specificElement, nil
+			}
+			return zero, &ElementError{Id: id, Err: fmt.Errorf("%w: expected %T, got %T", ErrTypeMismatch, zero, element)}
+		}
+	}
+	return zero, &ElementError{Id: id, Err: ErrElementNotFound}
+}
+
+// GetPoints resolves a list of point ids in order
+func GetPoints(s *Sketch, ids ...int) ([]*SketchPoint, error) {
+	points := make([]*SketchPoint, len(ids))
+	for i, id := range ids {
+		point, err := GetElementByID[*SketchPoint](s, id)
+		if err != nil {
+			return nil, err
+		}
+		points[i] = point
+	}
+	return points, nil
+}
+
+// GetLinePoints resolves the start and end points of a line
+func GetLinePoints(s *Sketch, lineId int) (*SketchPoint, *SketchPoint, error) {
+	line, err := GetElementByID[*SketchLine](s, lineId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	startPoint, err := GetElementByID[*SketchPoint](s, line.StartId)
+	if err != nil {
+		return nil, nil, err
+	}
+	endPoint, err := GetElementByID[*SketchPoint](s, line.EndId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return startPoint, endPoint, nil
+}
+
+// NextId returns an id that is not used by any element yet
+func (s *Sketch) NextId() int {
+	next := 0
+	for _, element := range s.Elements {
+		if element.GetId() >= next {
+			next = element.GetId() + 1
+		}
+	}
+	return next
+}
+
+func (s *Sketch) AddPoint(position geom.Vec2) *SketchPoint {
+	point := &SketchPoint{Id: s.NextId(), Position: position}
+	s.Elements = append(s.Elements, point)
+	return point
+}
+
+func (s *Sketch) AddLine(startId, endId int) *SketchLine {
+	line := &SketchLine{Id: s.NextId(), StartId: startId, EndId: endId}
+	s.Elements = append(s.Elements, line)
+	return line
+}
+
+func (s *Sketch) AddCircle(centerId int, radius float64) *SketchCircle {
+	circle := &SketchCircle{Id: s.NextId(), CenterId: centerId, Radius: radius}
+	s.Elements = append(s.Elements, circle)
+	return circle
+}
+
+func (s *Sketch) AddArc(centerId, startId, endId int) *SketchArc {
+	arc := &SketchArc{Id: s.NextId(), CenterId: centerId, StartId: startId, EndId: endId}
+	s.Elements = append(s.Elements, arc)
+	return arc
+}
+
+func (s *Sketch) GetClonedElements() []SketchElement {
+	elements := make([]SketchElement, len(s.Elements))
+	for i, element := range s.Elements {
+		elements[i] = element.Clone()
+	}
+	return elements
+}
+
+// Clone returns a deep copy of the sketch
+func (s *Sketch) Clone() *Sketch {
+	return &Sketch{
+		Elements:   s.GetClonedElements(),
+		Parameters: s.cloneParameters(),
+	}
+}
+
+// DeleteElement removes an element together with every element that refers to it,
+// such as the lines of a point or the constraints of a line. Returns the removed ids.
+// The origin and axes cannot be deleted
+func (s *Sketch) DeleteElement(id int) []int {
+	if IsBuiltin(id) {
+		return nil
+	}
+	removed := map[int]bool{id: true}
+	for changed := true; changed; {
+		changed = false
+		for _, element := range s.Elements {
+			if removed[element.GetId()] {
+				continue
+			}
+			for _, reference := range element.GetReferences() {
+				if removed[reference] {
+					removed[element.GetId()] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	ids := make([]int, 0, len(removed))
+	elements := make([]SketchElement, 0, len(s.Elements))
+	for _, element := range s.Elements {
+		if removed[element.GetId()] {
+			ids = append(ids, element.GetId())
+		} else {
+			elements = append(elements, element)
+		}
+	}
+	s.Elements = elements
+	return ids
+}
+
+func (s *Sketch) GetConstraints() []SketchConstraint {
+	constraints := make([]SketchConstraint, 0)
+	for _, element := range s.Elements {
+		if constraint, ok := element.(SketchConstraint); ok {
+			constraints = append(constraints, constraint)
+		}
+	}
+	return constraints
+}
+
+// SolveResult describes the outcome of AttemptApplyConstraints. The solver does not log,
+// callers report the result the way they need to
+type SolveResult struct {
+	Satisfied bool
+	// runs of the numeric solver, 0 when the constraints were already satisfied
+	Attempts int
+	// branch combinations the solver could be seeded from, 0 when it did not need them
+	Seeds int
+	// corners of the solution that turned inside out compared to the sketch before
+	Flips int
+	// constraints that reference missing or mistyped elements, they are left out of the solve
+	Invalid map[int]error
+}
+
+func (r SolveResult) String() string {
+	switch {
+	case !r.Satisfied:
+		return fmt.Sprintf("no solution found after %d attempts", r.Attempts)
+	case r.Attempts == 0:
+		return "constraints already satisfied"
+	case r.Seeds == 0:
+		return "constraints satisfied by the numeric solver"
+	}
+	return fmt.Sprintf("constraints satisfied after %d attempts from %d seeds, %d corners flipped", r.Attempts, r.Seeds, r.Flips)
+}
+
+// validateConstraints splits the constraints into the ones that can be evaluated
+// and the ones whose element references are broken. Reference dimensions are only
+// checked, the solver leaves them out
+func (s *Sketch) validateConstraints() ([]SketchConstraint, map[int]error) {
+	valid := make([]SketchConstraint, 0)
+	invalid := map[int]error{}
+	// implicit constraints come first, so the analysis blames redundancy on the constraints
+	// added on top of them. Broken geometry is reported when it is drawn
+	for _, element := range s.Elements {
+		geometry, ok := element.(SketchImplicit)
+		if !ok {
+			continue
+		}
+		for _, constraint := range geometry.GetImplicitConstraints() {
+			if _, err := constraint.GetResiduals(s); err == nil {
+				valid = append(valid, constraint)
+			}
+		}
+	}
+	for _, constraint := range s.GetConstraints() {
+		if _, err := constraint.GetDependencies(s); err != nil {
+			invalid[constraint.GetId()] = err
+			continue
+		}
+		if _, err := constraint.GetResiduals(s); err != nil {
+			invalid[constraint.GetId()] = err
+			continue
+		}
+		if isReferenceDimension(constraint) {
+			continue
+		}
+		valid = append(valid, constraint)
+	}
+	return valid, invalid
+}
+
+func (s *Sketch) allConstraintsSatisfied(constraints []SketchConstraint) (bool, error) {
+	for _, constraint := range constraints {
+		satisfied, err := constraint.IsSatisfied(s)
+		if err != nil {
+			return false, err
+		}
+		if !satisfied {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// solveNumerically runs the simultaneous solver from the current positions and
+// reverts the sketch if it does not converge
+func (s *Sketch) solveNumerically(constraints []SketchConstraint, locked map[int]bool) (bool, error) {
+	originalElements := s.GetClonedElements()
+
+	sys, err := newSolverSystem(s, constraints, locked)
+	if err != nil {
+		return false, err
+	}
+	if _, err := sys.solve(); err != nil {
+		s.Elements = originalElements
+		return false, err
+	}
+
+	satisfied, err := s.allConstraintsSatisfied(constraints)
+	if err != nil || !satisfied {
+		s.Elements = originalElements
+	}
+	return satisfied, err
+}
+
+// AttemptApplyConstraints moves the sketch points until every valid constraint is satisfied.
+// Constraints with broken element references are reported in the result instead of failing the solve
+func (s *Sketch) AttemptApplyConstraints() (SolveResult, error) {
+	constraints, invalid := s.validateConstraints()
+	result := SolveResult{Invalid: invalid}
+
+	satisfied, err := s.allConstraintsSatisfied(constraints)
+	if err != nil {
+		return result, err
+	}
+	if satisfied {
+		result.Satisfied = true
+		return result, nil
+	}
+
+	// the numeric solver usually lands on the solution closest to the current shape,
+	// it is only checked against the branch seeds when it turned corners inside out
+	reference := newSolveReference(s)
+	originalElements := s.GetClonedElements()
+	var best *solveCandidate
+
+	result.Attempts = 1
+	satisfied, err = s.solveNumerically(constraints, nil)
+	if err != nil {
+		return result, err
+	}
+	if satisfied {
+		best = reference.candidate(s)
+		if best.flips == 0 {
+			result.Satisfied = true
+			return result, nil
+		}
+		s.Elements = originalElements
+	}
+
+	// the solver got stuck in a local minimum or flipped the shape, use the constraint
+	// branches to seed it from different starting shapes
+	result.Seeds = branchCombinations(constraints)
+	attempts, best, err := s.attemptApplyBranches(constraints, reference, best)
+	result.Attempts += attempts
+	if err != nil {
+		return result, err
+	}
+	if best == nil {
+		return result, nil
+	}
+	s.Elements = best.elements
+	result.Satisfied = true
+	result.Flips = best.flips
+	return result, nil
+}
+
+// MovePoints moves points to new positions and re-solves the other constraints around
+// them while they are held in place. The sketch is left unchanged when no solution exists
+func (s *Sketch) MovePoints(targets map[int]geom.Vec2) (bool, error) {
+	originalElements := s.GetClonedElements()
+
+	locked := map[int]bool{}
+	for id, target := range targets {
+		if IsBuiltin(id) {
+			continue
+		}
+		point, err := GetElementByID[*SketchPoint](s, id)
+		if err != nil {
+			s.Elements = originalElements
+			return false, err
+		}
+		point.Position = target
+		locked[id] = true
+	}
+
+	constraints, _ := s.validateConstraints()
+	satisfied, err := s.solveNumerically(constraints, locked)
+	if err != nil || !satisfied {
+		s.Elements = originalElements
+	}
+	return satisfied, err
+}
+
+// maximum number of branch combinations tried as solver seeds
+const maxBranchAttempts = 1000
+
+// number of further branch combinations tried once a solution is known, looking for
+// one that is closer to the starting shape
+const maxCandidateAttempts = 64
+
+// branchCombinations is the number of ways the branches of the constraints can be combined,
+// it stops growing past what fits in an int
+func branchCombinations(constraints []SketchConstraint) int {
+	combinations := 1
+	for _, constraint := range constraints {
+		branches := constraint.GetBranches()
+		if branches > 0 && combinations > math.MaxInt/branches {
+			return math.MaxInt
+		}
+		combinations *= branches
+	}
+	return combinations
+}
+
+// attemptApplyBranches seeds the numeric solver with every combination of constraint
+// branches and returns the solution closest to the reference, or best when none is closer.
+// The sketch is left unchanged
+func (s *Sketch) attemptApplyBranches(constraints []SketchConstraint, reference *solveReference, best *solveCandidate) (int, *solveCandidate, error) {
+	// int array to store the number of branches for each constraint
+	branches := make([]int, len(constraints))
+	currentBranches := make([]int, len(constraints))
+
+	// get the number of branches for each constraint
+	for i, constraint := range constraints {
+		branches[i] = constraint.GetBranches()
+		currentBranches[i] = 0
+	}
+
+	attempts := 0
+	// attempts left once a solution is known
+	remaining := maxCandidateAttempts
+	anchored := getAnchoredPoints(constraints)
+
+	for attempts < maxBranchAttempts && (best == nil || remaining > 0) {
+		attempts++
+		if best != nil {
+			remaining--
+		}
+		// deep clone the sketch
+		originalElements := s.GetClonedElements()
+
+		for i, constraint := range constraints {
+			satisfied, err := constraint.IsSatisfied(s)
+			if err != nil {
+				s.Elements = originalElements
+				return attempts, nil, err
+			}
+			if satisfied {
+				continue
+			}
+			beforeApply := s.GetClonedElements()
+			if _, err := constraint.Apply(s, currentBranches[i]); err != nil {
+				s.Elements = originalElements
+				return attempts, nil, err
+			}
+			// a branch may not drag the origin or a fixed point along
+			if _, ok := constraint.(*SketchConstraintFixed); !ok && s.movedAnyPoint(beforeApply, anchored) {
+				s.Elements = beforeApply
+			}
+		}
+
+		satisfied, err := s.solveNumerically(constraints, nil)
+		if err != nil {
+			s.Elements = originalElements
+			return attempts, nil, err
+		}
+		if satisfied {
+			if candidate := reference.candidate(s); candidate.closerThan(best) {
+				best = candidate
+			}
+		}
+
+		// revert to the previous state
+		s.Elements = originalElements
+
+		i := 0
+
+		currentBranches[i]++
+		for currentBranches[i] >= branches[i] {
+			currentBranches[i] = 0
+			i++
+			if i >= len(currentBranches) {
+				return attempts, best, nil
+			}
+			currentBranches[i]++
+		}
+	}
+
+	return attempts, best, nil
+}
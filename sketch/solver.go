@@ -0,0 +1,251 @@
+package sketch
+
+import (
+	"math"
+)
+
+const (
+	solverMaxIterations = 200
+	solverTolerance     = 1e-10
+	solverStep          = 1e-7
+	solverMinDamping    = 1e-12
+	solverMaxDamping    = 1e12
+)
+
+// SketchVariables is implemented by elements whose state the solver is allowed to move
+type SketchVariables interface {
+	getVariables() []*float64
+}
+
+// solverSystem gathers the residual equations of a set of constraints over the
+// variables of the elements they depend on
+type solverSystem struct {
+	s           *Sketch
+	constraints []SketchConstraint
+	variables   []*float64
+	// variable indices that have to stay positive, like the radius of a circle
+	positive []int
+	// variable indices of each element that has variables
+	elementColumns map[int][]int
+	// element ids each constraint depends on
+	dependencies [][]int
+	// variable indices each constraint depends on, the jacobian is zero elsewhere
+	columns [][]int
+	// index of the first residual row of each constraint
+	rows     []int
+	rowCount int
+}
+
+// newSolverSystem builds the equations of the constraints, elements in locked and the
+// origin keep their variables fixed
+func newSolverSystem(s *Sketch, constraints []SketchConstraint, locked map[int]bool) (*solverSystem, error) {
+	sys := &solverSystem{
+		s:              s,
+		constraints:    constraints,
+		dependencies:   make([][]int, len(constraints)),
+		columns:        make([][]int, len(constraints)),
+		rows:           make([]int, len(constraints)),
+		elementColumns: map[int][]int{},
+	}
+
+	for _, element := range s.Elements {
+		if locked[element.GetId()] || IsBuiltin(element.GetId()) {
+			continue
+		}
+		if v, ok := element.(SketchVariables); ok {
+			for _, variable := range v.getVariables() {
+				if circle, ok := element.(*SketchCircle); ok && variable == &circle.Radius {
+					sys.positive = append(sys.positive, len(sys.variables))
+				}
+				sys.elementColumns[element.GetId()] = append(sys.elementColumns[element.GetId()], len(sys.variables))
+				sys.variables = append(sys.variables, variable)
+			}
+		}
+	}
+
+	for i, constraint := range constraints {
+		dependencies, err := constraint.GetDependencies(s)
+		if err != nil {
+			return nil, err
+		}
+		residuals, err := constraint.GetResiduals(s)
+		if err != nil {
+			return nil, err
+		}
+
+		sys.dependencies[i] = dependencies
+		seen := map[int]bool{}
+		for _, id := range dependencies {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			sys.columns[i] = append(sys.columns[i], sys.elementColumns[id]...)
+		}
+		sys.rows[i] = sys.rowCount
+		sys.rowCount += len(residuals)
+	}
+
+	return sys, nil
+}
+
+// number of residual rows of the constraint at index i
+func (sys *solverSystem) rowsOf(i int) int {
+	if i+1 < len(sys.rows) {
+		return sys.rows[i+1] - sys.rows[i]
+	}
+	return sys.rowCount - sys.rows[i]
+}
+
+func (sys *solverSystem) getValues() []float64 {
+	values := make([]float64, len(sys.variables))
+	for i, variable := range sys.variables {
+		values[i] = *variable
+	}
+	return values
+}
+
+func (sys *solverSystem) setValues(values []float64) {
+	for i, variable := range sys.variables {
+		*variable = values[i]
+	}
+}
+
+func (sys *solverSystem) residuals() ([]float64, error) {
+	residuals := make([]float64, 0, sys.rowCount)
+	for _, constraint := range sys.constraints {
+		r, err := constraint.GetResiduals(sys.s)
+		if err != nil {
+			return nil, err
+		}
+		residuals = append(residuals, r...)
+	}
+	return residuals, nil
+}
+
+// numeric jacobian using central differences, one column per variable
+func (sys *solverSystem) jacobian() ([][]float64, error) {
+	jacobian := newMatrix(sys.rowCount, len(sys.variables))
+	for i, constraint := range sys.constraints {
+		for _, col := range sys.columns[i] {
+			variable := sys.variables[col]
+			original := *variable
+			h := solverStep * math.Max(1, math.Abs(original))
+
+			*variable = original + h
+			plus, err := constraint.GetResiduals(sys.s)
+			if err != nil {
+				*variable = original
+				return nil, err
+			}
+			*variable = original - h
+			minus, err := constraint.GetResiduals(sys.s)
+			*variable = original
+			if err != nil {
+				return nil, err
+			}
+
+			for k := range plus {
+				jacobian[sys.rows[i]+k][col] = (plus[k] - minus[k]) / (2 * h)
+			}
+		}
+	}
+	return jacobian, nil
+}
+
+// solve minimizes the squared residuals with damped least squares steps (Levenberg–Marquardt).
+// returns true when every residual reached the tolerance
+func (sys *solverSystem) solve() (bool, error) {
+	if sys.rowCount == 0 {
+		return true, nil
+	}
+
+	x := sys.getValues()
+	r, err := sys.residuals()
+	if err != nil {
+		return false, err
+	}
+	cost := sumOfSquares(r)
+	damping := 1e-3
+
+	for iteration := 0; iteration < solverMaxIterations; iteration++ {
+		if maxAbs(r) < solverTolerance {
+			return true, nil
+		}
+
+		jacobian, err := sys.jacobian()
+		if err != nil {
+			return false, err
+		}
+		jtj, jtr := normalEquations(jacobian, r, len(x))
+
+		improved := false
+		for damping < solverMaxDamping {
+			a := cloneMatrix(jtj)
+			b := make([]float64, len(x))
+			for i := range a {
+				a[i][i] += damping * (1 + jtj[i][i])
+				b[i] = -jtr[i]
+			}
+
+			delta, ok := solveLinearSystem(a, b)
+			if ok {
+				candidate := make([]float64, len(x))
+				for i := range x {
+					candidate[i] = x[i] + delta[i]
+				}
+				sys.keepPositive(x, candidate)
+				sys.setValues(candidate)
+				candidateResiduals, err := sys.residuals()
+				if err != nil {
+					sys.setValues(x)
+					return false, err
+				}
+				candidateCost := sumOfSquares(candidateResiduals)
+				if candidateCost < cost {
+					x = candidate
+					r = candidateResiduals
+					cost = candidateCost
+					damping = math.Max(damping/10, solverMinDamping)
+					improved = true
+					break
+				}
+			}
+			damping *= 10
+		}
+
+		sys.setValues(x)
+		if !improved {
+			// stuck in a local minimum
+			break
+		}
+	}
+
+	return maxAbs(r) < solverTolerance, nil
+}
+
+// keepPositive shortens the step of the variables that would become zero or negative to
+// half the way there, so a circle cannot turn inside out
+func (sys *solverSystem) keepPositive(x, candidate []float64) {
+	for _, i := range sys.positive {
+		if candidate[i] <= 0 && x[i] > 0 {
+			candidate[i] = x[i] / 2
+		}
+	}
+}
+
+func sumOfSquares(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v * v
+	}
+	return sum
+}
+
+func maxAbs(values []float64) float64 {
+	max := 0.0
+	for _, v := range values {
+		max = math.Max(max, math.Abs(v))
+	}
+	return max
+}
@@ -0,0 +1,210 @@
+package sketch
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"unholy-cad/geom"
+)
+
+// triangleSketch has a triangle fixed at its first corner with a horizontal base, and the
+// point ids of its corners
+func triangleSketch(apex geom.Vec2) (*Sketch, [3]int) {
+	s := NewSketch()
+	a := s.AddPoint(geom.Vec2{X: 0, Y: 0})
+	b := s.AddPoint(geom.Vec2{X: 35, Y: 3})
+	c := s.AddPoint(apex)
+	ab, bc, ca := s.AddLine(a.Id, b.Id), s.AddLine(b.Id, c.Id), s.AddLine(c.Id, a.Id)
+	s.Elements = append(s.Elements,
+		&SketchConstraintFixed{Id: s.NextId(), PointId: a.Id, Position: a.Position},
+		&SketchConstraintHorizontal{Id: s.NextId(), Point1Id: a.Id, Point2Id: b.Id, LineId: &ab.Id},
+		&SketchConstraintLineLength{Id: s.NextId(), LineId: ab.Id, Length: 40},
+		&SketchConstraintLineLength{Id: s.NextId(), LineId: bc.Id, Length: 50},
+		&SketchConstraintLineLength{Id: s.NextId(), LineId: ca.Id, Length: 30},
+	)
+	return s, [3]int{a.Id, b.Id, c.Id}
+}
+
+func pointPosition(t *testing.T, s *Sketch, id int) geom.Vec2 {
+	t.Helper()
+	point, err := GetElementByID[*SketchPoint](s, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return point.Position
+}
+
+func TestSolverConverges(t *testing.T) {
+	s, corners := triangleSketch(geom.Vec2{X: 2, Y: 25})
+	result, err := s.AttemptApplyConstraints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Satisfied || result.Attempts != 1 || result.Seeds != 0 {
+		t.Fatalf("got %+v, want one numeric solve", result)
+	}
+
+	// the solver clones the elements, the positions are read again
+	a, b, c := pointPosition(t, s, corners[0]), pointPosition(t, s, corners[1]), pointPosition(t, s, corners[2])
+	if a.Magnitude() > 1e-6 {
+		t.Errorf("fixed point moved to %v", a)
+	}
+	for _, check := range []struct {
+		got, want float64
+	}{
+		{b.Y, 0},
+		{a.DistanceTo(b), 40},
+		{b.DistanceTo(c), 50},
+		{c.DistanceTo(a), 30},
+	} {
+		if math.Abs(check.got-check.want) > 1e-6 {
+			t.Errorf("got %v, want %v", check.got, check.want)
+		}
+	}
+
+	// solving again has nothing to do
+	result, err = s.AttemptApplyConstraints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Satisfied || result.Attempts != 0 {
+		t.Errorf("got %+v, want already satisfied", result)
+	}
+}
+
+func TestSolverConflict(t *testing.T) {
+	s, corners := triangleSketch(geom.Vec2{X: 2, Y: 25})
+	// the sides are 40 and 30, the base cannot be 80 as well
+	s.Elements = append(s.Elements, &SketchConstraintPointDistance{Id: s.NextId(), Point1Id: corners[1], Point2Id: corners[2], Direction: DistanceAligned, Distance: 80})
+	before := s.Clone()
+
+	result, err := s.AttemptApplyConstraints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Satisfied {
+		t.Fatalf("conflicting constraints satisfied: %+v", result)
+	}
+	if result.Seeds == 0 || result.Attempts < 2 {
+		t.Errorf("got %+v, want the branch seeds tried", result)
+	}
+	if !reflect.DeepEqual(s.Elements, before.Elements) {
+		t.Error("failed solve changed the sketch")
+	}
+}
+
+func TestSolverKeepsBranch(t *testing.T) {
+	// the apex can be on either side of the base, the solver keeps it on the side it
+	// started on, with y pointing down
+	tests := []struct {
+		name string
+		apex geom.Vec2
+		want geom.Vec2
+	}{
+		{"below", geom.Vec2{X: 2, Y: 25}, geom.Vec2{X: 0, Y: 30}},
+		{"above", geom.Vec2{X: 2, Y: -25}, geom.Vec2{X: 0, Y: -30}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, corners := triangleSketch(test.apex)
+			result, err := s.AttemptApplyConstraints()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.Satisfied || result.Flips != 0 {
+				t.Fatalf("got %+v, want a solution without flips", result)
+			}
+			if got := pointPosition(t, s, corners[2]); got.DistanceTo(test.want) > 1e-6 {
+				t.Errorf("apex at %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSolveCandidates(t *testing.T) {
+	s, corners := triangleSketch(geom.Vec2{X: 0, Y: 30})
+	reference := newSolveReference(s)
+
+	same := reference.candidate(s)
+	if same.flips != 0 || same.displacement != 0 {
+		t.Errorf("unchanged sketch has %d flips and displacement %v", same.flips, same.displacement)
+	}
+
+	// mirrored about the base every corner turns the other way
+	apex, _ := GetElementByID[*SketchPoint](s, corners[2])
+	apex.Position.Y = -30
+	mirrored := reference.candidate(s)
+	if mirrored.flips != 3 {
+		t.Errorf("mirrored triangle has %d flips, want 3", mirrored.flips)
+	}
+
+	// moved further but the same way around
+	apex.Position = geom.Vec2{X: 5, Y: 95}
+	moved := reference.candidate(s)
+	if moved.flips != 0 || moved.displacement <= mirrored.displacement {
+		t.Errorf("moved triangle has %d flips and displacement %v", moved.flips, moved.displacement)
+	}
+	if !moved.closerThan(mirrored) || mirrored.closerThan(moved) {
+		t.Error("flipped corners are preferred over moving further")
+	}
+	if !same.closerThan(moved) || !same.closerThan(nil) {
+		t.Error("more movement is preferred")
+	}
+}
+
+// negativeRadius pulls the radius of a circle towards a value below zero
+type negativeRadius struct {
+	circle *SketchCircle
+}
+
+func (c *negativeRadius) Clone() SketchElement {
+	return &negativeRadius{circle: c.circle}
+}
+
+func (c *negativeRadius) GetId() int {
+	return 100
+}
+
+func (c *negativeRadius) GetReferences() []int {
+	return []int{c.circle.Id}
+}
+
+func (c *negativeRadius) GetBranches() int {
+	return 1
+}
+
+func (c *negativeRadius) Apply(s *Sketch, branch int) (bool, error) {
+	return false, nil
+}
+
+func (c *negativeRadius) IsSatisfied(s *Sketch) (bool, error) {
+	return false, nil
+}
+
+func (c *negativeRadius) GetDependencies(s *Sketch) ([]int, error) {
+	return []int{c.circle.Id}, nil
+}
+
+func (c *negativeRadius) GetResiduals(s *Sketch) ([]float64, error) {
+	return []float64{c.circle.Radius + 5}, nil
+}
+
+func TestSolverKeepsRadiusPositive(t *testing.T) {
+	s := NewSketch()
+	circle := s.AddCircle(s.AddPoint(geom.Vec2{X: 0, Y: 0}).Id, 10)
+	sys, err := newSolverSystem(s, []SketchConstraint{&negativeRadius{circle: circle}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	satisfied, err := sys.solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if satisfied {
+		t.Error("negative radius reached")
+	}
+	if !(circle.Radius > 0) {
+		t.Errorf("radius %v, want it to stay positive", circle.Radius)
+	}
+}
@@ -0,0 +1,483 @@
+package sketch
+
+import (
+	"math"
+	"sort"
+
+	"unholy-cad/geom"
+)
+
+// LoopEdge is a line, arc or circle of a loop and the direction it is walked in
+type LoopEdge struct {
+	Id int
+	// walked from the end point to the start point
+	Reversed bool
+}
+
+// Loop is a closed boundary. Outer boundaries run with increasing angle, which is
+// clockwise on screen, and holes the other way, so their signed areas add up
+type Loop struct {
+	Edges []LoopEdge
+}
+
+// Region is an area enclosed by a loop, without the holes inside it
+type Region struct {
+	Outer Loop
+	Holes []Loop
+}
+
+type Topology struct {
+	Regions []Region
+	// lines and arcs that are not part of any loop, each chain in order from one end
+	OpenChains [][]int
+	// points where a single line or arc ends
+	Dangling []int
+	// dangling points that are closest to each other, as those are likely meant to meet
+	Gaps [][2]int
+}
+
+// segment is a line or arc walked in one direction
+type segment struct {
+	from, to geom.Vec2
+	arc      bool
+	// arcs only, the sweep is negative when the arc is walked backwards
+	center geom.Vec2
+	radius float64
+	sweep  float64
+}
+
+// segment resolves the geometry of an edge in its direction
+func (e LoopEdge) segment(s *Sketch) (segment, error) {
+	element, err := GetElementByID[SketchElement](s, e.Id)
+	if err != nil {
+		return segment{}, err
+	}
+	var seg segment
+	switch el := element.(type) {
+	case *SketchLine:
+		start, end, err := GetLinePoints(s, el.Id)
+		if err != nil {
+			return segment{}, err
+		}
+		seg = segment{from: start.Position, to: end.Position}
+	case *SketchArc:
+		center, start, end, err := el.GetPoints(s)
+		if err != nil {
+			return segment{}, err
+		}
+		_, sweep, err := el.GetAngles(s)
+		if err != nil {
+			return segment{}, err
+		}
+		seg = segment{
+			from:   start.Position,
+			to:     end.Position,
+			arc:    true,
+			center: center.Position,
+			radius: center.Position.DistanceTo(start.Position),
+			sweep:  sweep,
+		}
+	case *SketchCircle:
+		center, err := el.GetCenter(s)
+		if err != nil {
+			return segment{}, err
+		}
+		rim := center.Position.Add(geom.Vec2{X: el.Radius})
+		seg = segment{from: rim, to: rim, arc: true, center: center.Position, radius: el.Radius, sweep: 2 * math.Pi}
+	default:
+		return segment{}, &ElementError{Id: e.Id, Err: ErrTypeMismatch}
+	}
+
+	if e.Reversed {
+		seg.from, seg.to = seg.to, seg.from
+		seg.sweep = -seg.sweep
+	}
+	return seg, nil
+}
+
+// direction returns the direction the segment leaves its first point in
+func (seg segment) direction() geom.Vec2 {
+	if !seg.arc {
+		return seg.to.Sub(seg.from)
+	}
+	return seg.from.Sub(seg.center).Tangent().Mul(math.Copysign(1, seg.sweep))
+}
+
+// area returns the signed area between the segment and the origin
+func (seg segment) area() float64 {
+	area := (seg.from.X*seg.to.Y - seg.to.X*seg.from.Y) / 2
+	if seg.arc {
+		// the circular segment between the chord and the arc
+		area += seg.radius * seg.radius / 2 * (seg.sweep - math.Sin(seg.sweep))
+	}
+	return area
+}
+
+// points returns points along the segment from its first point, without the last one.
+// Arcs get a point every few degrees
+func (seg segment) points() []geom.Vec2 {
+	if !seg.arc {
+		return []geom.Vec2{seg.from}
+	}
+	count := int(math.Max(2, math.Ceil(math.Abs(seg.sweep)/(3*math.Pi/180))))
+	start := seg.from.Sub(seg.center)
+	startAngle := math.Atan2(start.Y, start.X)
+	points := make([]geom.Vec2, 0, count)
+	for i := 0; i < count; i++ {
+		angle := startAngle + seg.sweep*float64(i)/float64(count)
+		points = append(points, seg.center.Add(geom.Vec2{X: math.Cos(angle), Y: math.Sin(angle)}.Mul(seg.radius)))
+	}
+	return points
+}
+
+func (l Loop) segments(s *Sketch) ([]segment, error) {
+	segments := make([]segment, 0, len(l.Edges))
+	for _, edge := range l.Edges {
+		seg, err := edge.segment(s)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, seg)
+	}
+	return segments, nil
+}
+
+// Area returns the signed area enclosed by the loop, positive for outer boundaries and
+// negative for holes
+func (l Loop) Area(s *Sketch) (float64, error) {
+	segments, err := l.segments(s)
+	if err != nil {
+		return 0, err
+	}
+	area := 0.0
+	for _, seg := range segments {
+		area += seg.area()
+	}
+	return area, nil
+}
+
+// Points returns the loop as a polygon, arcs are split into short lines
+func (l Loop) Points(s *Sketch) ([]geom.Vec2, error) {
+	segments, err := l.segments(s)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]geom.Vec2, 0)
+	for _, seg := range segments {
+		points = append(points, seg.points()...)
+	}
+	return points, nil
+}
+
+// contains reports whether a position is inside a polygon, by counting the edges a ray
+// to the right crosses
+func contains(polygon []geom.Vec2, p geom.Vec2) bool {
+	inside := false
+	for i := range polygon {
+		a, b := polygon[i], polygon[(i+1)%len(polygon)]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < a.X+(p.Y-a.Y)/(b.Y-a.Y)*(b.X-a.X) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// halfEdge is one direction of a line or arc in the loop search
+type halfEdge struct {
+	edge     LoopEdge
+	from, to int
+	angle    float64
+	next     int
+	visited  bool
+}
+
+// face is a loop found by walking the half edges, with the component it belongs to
+type face struct {
+	loop      Loop
+	area      float64
+	polygon   []geom.Vec2
+	component int
+}
+
+// findGaps pairs up the dangling points that are each other's closest dangling point
+func (s *Sketch) findGaps(dangling []int) ([][2]int, error) {
+	positions := make([]geom.Vec2, len(dangling))
+	for i, id := range dangling {
+		point, err := GetElementByID[*SketchPoint](s, id)
+		if err != nil {
+			return nil, err
+		}
+		positions[i] = point.Position
+	}
+	closest := make([]int, len(positions))
+	for i := range positions {
+		closest[i] = -1
+		for j := range positions {
+			if j != i && (closest[i] < 0 || positions[i].DistanceTo(positions[j]) < positions[i].DistanceTo(positions[closest[i]])) {
+				closest[i] = j
+			}
+		}
+	}
+	gaps := make([][2]int, 0)
+	for i, j := range closest {
+		if j > i && closest[j] == i {
+			gaps = append(gaps, [2]int{dangling[i], dangling[j]})
+		}
+	}
+	return gaps, nil
+}
+
+// AnalyzeTopology finds the closed loops formed by lines and arcs through shared points,
+// and circles, and sorts them into regions with holes. Loops nested inside a hole are
+// regions again. Lines and arcs that do not close are reported as open chains. Geometry
+// is expected to only touch at shared points
+func (s *Sketch) AnalyzeTopology() (Topology, error) {
+	topology := Topology{
+		Regions:    make([]Region, 0),
+		OpenChains: make([][]int, 0),
+		Dangling:   make([]int, 0),
+		Gaps:       make([][2]int, 0),
+	}
+
+	type edge struct {
+		id         int
+		start, end int
+	}
+	edges := make([]edge, 0)
+	circles := make([]int, 0)
+	degree := map[int]int{}
+	for _, element := range s.Elements {
+		if IsBuiltin(element.GetId()) {
+			continue
+		}
+		var e edge
+		switch el := element.(type) {
+		case *SketchLine:
+			e = edge{id: el.Id, start: el.StartId, end: el.EndId}
+		case *SketchArc:
+			e = edge{id: el.Id, start: el.StartId, end: el.EndId}
+		case *SketchCircle:
+			circles = append(circles, el.Id)
+			continue
+		default:
+			continue
+		}
+		if e.start == e.end {
+			continue
+		}
+		edges = append(edges, e)
+		degree[e.start]++
+		degree[e.end]++
+	}
+
+	for pointId, count := range degree {
+		if count == 1 {
+			topology.Dangling = append(topology.Dangling, pointId)
+		}
+	}
+	sort.Ints(topology.Dangling)
+	gaps, err := s.findGaps(topology.Dangling)
+	if err != nil {
+		return Topology{}, err
+	}
+	topology.Gaps = gaps
+
+	// strip the edges leading to dead ends until only the loops are left
+	remaining := map[int]int{}
+	at := map[int][]int{}
+	for i, e := range edges {
+		remaining[e.start]++
+		remaining[e.end]++
+		at[e.start] = append(at[e.start], i)
+		at[e.end] = append(at[e.end], i)
+	}
+	open := make([]bool, len(edges))
+	queue := append([]int{}, topology.Dangling...)
+	for len(queue) > 0 {
+		pointId := queue[0]
+		queue = queue[1:]
+		for _, i := range at[pointId] {
+			if open[i] {
+				continue
+			}
+			open[i] = true
+			remaining[edges[i].start]--
+			remaining[edges[i].end]--
+			other := edges[i].start
+			if other == pointId {
+				other = edges[i].end
+			}
+			if remaining[other] == 1 {
+				queue = append(queue, other)
+			}
+		}
+	}
+
+	// open chains run between points where the geometry does not simply continue
+	walked := make([]bool, len(edges))
+	walk := func(i, from int) []int {
+		chain := make([]int, 0)
+		for {
+			walked[i] = true
+			chain = append(chain, edges[i].id)
+			to := edges[i].end
+			if from == to {
+				to = edges[i].start
+			}
+			if degree[to] != 2 {
+				return chain
+			}
+			next := -1
+			for _, j := range at[to] {
+				if j != i && open[j] && !walked[j] {
+					next = j
+				}
+			}
+			if next < 0 {
+				return chain
+			}
+			i, from = next, to
+		}
+	}
+	for i, e := range edges {
+		if !open[i] || walked[i] {
+			continue
+		}
+		if degree[e.start] != 2 {
+			topology.OpenChains = append(topology.OpenChains, walk(i, e.start))
+		} else if degree[e.end] != 2 {
+			topology.OpenChains = append(topology.OpenChains, walk(i, e.end))
+		}
+	}
+
+	// both directions of every edge of a loop, sorted by the direction they leave
+	// their point in
+	halfEdges := make([]*halfEdge, 0)
+	outgoing := map[int][]int{}
+	for i, e := range edges {
+		if open[i] {
+			continue
+		}
+		for _, reversed := range []bool{false, true} {
+			loopEdge := LoopEdge{Id: e.id, Reversed: reversed}
+			seg, err := loopEdge.segment(s)
+			if err != nil {
+				return Topology{}, err
+			}
+			h := &halfEdge{edge: loopEdge, from: e.start, to: e.end}
+			if reversed {
+				h.from, h.to = e.end, e.start
+			}
+			direction := seg.direction()
+			h.angle = math.Atan2(direction.Y, direction.X)
+			outgoing[h.from] = append(outgoing[h.from], len(halfEdges))
+			halfEdges = append(halfEdges, h)
+		}
+	}
+	for _, list := range outgoing {
+		sort.Slice(list, func(a, b int) bool { return halfEdges[list[a]].angle < halfEdges[list[b]].angle })
+	}
+	// after arriving at a point, leave on the edge just before the way back, so every
+	// face is walked with increasing angle except the one around the outside
+	for i, h := range halfEdges {
+		twin := i ^ 1
+		list := outgoing[h.to]
+		for k, j := range list {
+			if j == twin {
+				h.next = list[(k+len(list)-1)%len(list)]
+				break
+			}
+		}
+	}
+
+	// points of a component are connected by loops
+	component := map[int]int{}
+	var mark func(pointId, c int)
+	mark = func(pointId, c int) {
+		if _, ok := component[pointId]; ok {
+			return
+		}
+		component[pointId] = c
+		for _, i := range outgoing[pointId] {
+			mark(halfEdges[i].to, c)
+		}
+	}
+	componentCount := 0
+	for _, h := range halfEdges {
+		if _, ok := component[h.from]; !ok {
+			mark(h.from, componentCount)
+			componentCount++
+		}
+	}
+
+	faces := make([]*face, 0)
+	for i, h := range halfEdges {
+		if h.visited {
+			continue
+		}
+		f := &face{component: component[h.from]}
+		for j := i; !halfEdges[j].visited; j = halfEdges[j].next {
+			halfEdges[j].visited = true
+			f.loop.Edges = append(f.loop.Edges, halfEdges[j].edge)
+		}
+		faces = append(faces, f)
+	}
+	for _, id := range circles {
+		c := componentCount
+		componentCount++
+		faces = append(faces,
+			&face{loop: Loop{Edges: []LoopEdge{{Id: id}}}, component: c},
+			&face{loop: Loop{Edges: []LoopEdge{{Id: id, Reversed: true}}}, component: c})
+	}
+
+	// the face with the smallest area of each component runs around its outside
+	outside := make([]*face, componentCount)
+	for _, f := range faces {
+		area, err := f.loop.Area(s)
+		if err != nil {
+			return Topology{}, err
+		}
+		polygon, err := f.loop.Points(s)
+		if err != nil {
+			return Topology{}, err
+		}
+		f.area, f.polygon = area, polygon
+		if outside[f.component] == nil || f.area < outside[f.component].area {
+			outside[f.component] = f
+		}
+	}
+
+	// a component lies in the smallest face of another component around it
+	container := make([]*face, componentCount)
+	for c, outline := range outside {
+		sample := outline.polygon[0]
+		for _, f := range faces {
+			if f.component == c || f == outside[f.component] || !contains(f.polygon, sample) {
+				continue
+			}
+			if container[c] == nil || f.area < container[c].area {
+				container[c] = f
+			}
+		}
+	}
+	depth := make([]int, componentCount)
+	for c := range depth {
+		for f := container[c]; f != nil; f = container[f.component] {
+			depth[c]++
+		}
+	}
+
+	for _, f := range faces {
+		if f == outside[f.component] || depth[f.component]%2 != 0 {
+			continue
+		}
+		region := Region{Outer: f.loop, Holes: make([]Loop, 0)}
+		for c, outline := range outside {
+			if container[c] == f {
+				region.Holes = append(region.Holes, outline.loop)
+			}
+		}
+		topology.Regions = append(topology.Regions, region)
+	}
+	return topology, nil
+}
@@ -0,0 +1,162 @@
+package sketch
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"unholy-cad/geom"
+)
+
+// addRectangle adds a closed rectangle of lines from x0, y0 to x1, y1
+func addRectangle(s *Sketch, x0, y0, x1, y1 float64) {
+	corners := []*SketchPoint{
+		s.AddPoint(geom.Vec2{X: x0, Y: y0}),
+		s.AddPoint(geom.Vec2{X: x1, Y: y0}),
+		s.AddPoint(geom.Vec2{X: x1, Y: y1}),
+		s.AddPoint(geom.Vec2{X: x0, Y: y1}),
+	}
+	for i, corner := range corners {
+		s.AddLine(corner.Id, corners[(i+1)%len(corners)].Id)
+	}
+}
+
+func TestAnalyzeTopology(t *testing.T) {
+	tests := []struct {
+		name   string
+		sketch func() *Sketch
+		// area of the outer loop and of the holes of each region
+		regions    [][]float64
+		openChains [][]int
+		dangling   []int
+		gaps       [][2]int
+	}{
+		{
+			name: "square",
+			sketch: func() *Sketch {
+				s := NewSketch()
+				addRectangle(s, 0, 0, 10, 10)
+				return s
+			},
+			regions: [][]float64{{100}},
+		},
+		{
+			name: "square with a hole",
+			sketch: func() *Sketch {
+				s := NewSketch()
+				addRectangle(s, 0, 0, 10, 10)
+				addRectangle(s, 2, 2, 6, 6)
+				return s
+			},
+			regions: [][]float64{{100, -16}},
+		},
+		{
+			name: "square with a round hole",
+			sketch: func() *Sketch {
+				s := NewSketch()
+				addRectangle(s, 0, 0, 10, 10)
+				s.AddCircle(s.AddPoint(geom.Vec2{X: 5, Y: 5}).Id, 2)
+				return s
+			},
+			regions: [][]float64{{100, -4 * math.Pi}},
+		},
+		{
+			// the island inside the hole is a region of its own
+			name: "island",
+			sketch: func() *Sketch {
+				s := NewSketch()
+				addRectangle(s, 0, 0, 10, 10)
+				addRectangle(s, 2, 2, 8, 8)
+				addRectangle(s, 4, 4, 6, 6)
+				return s
+			},
+			regions: [][]float64{{100, -36}, {4}},
+		},
+		{
+			// points 0 to 3, lines 4 to 6
+			name: "open chain",
+			sketch: func() *Sketch {
+				s := NewSketch()
+				a, b := s.AddPoint(geom.Vec2{X: 0, Y: 0}), s.AddPoint(geom.Vec2{X: 10, Y: 0})
+				c, d := s.AddPoint(geom.Vec2{X: 10, Y: 10}), s.AddPoint(geom.Vec2{X: 1, Y: 10})
+				s.AddLine(a.Id, b.Id)
+				s.AddLine(b.Id, c.Id)
+				s.AddLine(c.Id, d.Id)
+				return s
+			},
+			regions:    [][]float64{},
+			openChains: [][]int{{4, 5, 6}},
+			dangling:   []int{0, 3},
+			gaps:       [][2]int{{0, 3}},
+		},
+		{
+			// ends that are each other's closest end are likely meant to meet, the far
+			// ends are closer to another end than to each other. Points 0 to 5, lines 6 to 8
+			name: "separate lines",
+			sketch: func() *Sketch {
+				s := NewSketch()
+				for _, x := range []float64{0, 11, 30} {
+					s.AddPoint(geom.Vec2{X: x, Y: 0})
+					s.AddPoint(geom.Vec2{X: x + 10, Y: 0})
+				}
+				for id := 0; id < 6; id += 2 {
+					s.AddLine(id, id+1)
+				}
+				return s
+			},
+			regions:    [][]float64{},
+			openChains: [][]int{{6}, {7}, {8}},
+			dangling:   []int{0, 1, 2, 3, 4, 5},
+			gaps:       [][2]int{{1, 2}, {3, 4}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := test.sketch()
+			topology, err := s.AnalyzeTopology()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			regions := make([][]float64, 0, len(topology.Regions))
+			for _, region := range topology.Regions {
+				areas := make([]float64, 0, 1+len(region.Holes))
+				for _, loop := range append([]Loop{region.Outer}, region.Holes...) {
+					area, err := loop.Area(s)
+					if err != nil {
+						t.Fatal(err)
+					}
+					areas = append(areas, math.Round(area*1e6)/1e6)
+				}
+				regions = append(regions, areas)
+			}
+			for _, areas := range test.regions {
+				for i := range areas {
+					areas[i] = math.Round(areas[i]*1e6) / 1e6
+				}
+			}
+			if !reflect.DeepEqual(regions, test.regions) {
+				t.Errorf("regions with areas %v, want %v", regions, test.regions)
+			}
+
+			if test.openChains == nil {
+				test.openChains = [][]int{}
+			}
+			if test.dangling == nil {
+				test.dangling = []int{}
+			}
+			if test.gaps == nil {
+				test.gaps = [][2]int{}
+			}
+			if !reflect.DeepEqual(topology.OpenChains, test.openChains) {
+				t.Errorf("open chains %v, want %v", topology.OpenChains, test.openChains)
+			}
+			if !reflect.DeepEqual(topology.Dangling, test.dangling) {
+				t.Errorf("dangling %v, want %v", topology.Dangling, test.dangling)
+			}
+			if !reflect.DeepEqual(topology.Gaps, test.gaps) {
+				t.Errorf("gaps %v, want %v", topology.Gaps, test.gaps)
+			}
+		})
+	}
+}
@@ -1,3 +1,5 @@
+//go:build !headless
+
 package main
 
 import (
@@ -8,6 +10,9 @@ import (
 	"github.com/hajimehoshi/ebiten/examples/resources/fonts"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text/v2"
+
+	"unholy-cad/geom"
+	"unholy-cad/view"
 )
 
 var (
@@ -25,7 +30,7 @@ func initFonts() {
 
 	mplusNormalFace = &text.GoTextFace{
 		Source: mplusFaceSource,
-		Size:   14,
+		Size:   view.FontSize,
 	}
 	mplusBigFace = &text.GoTextFace{
 		Source: mplusFaceSource,
@@ -33,9 +38,13 @@ func initFonts() {
 	}
 }
 
-func DrawText(dst *ebiten.Image, str string, pos Vec2, clr color.Color) {
+func DrawText(dst *ebiten.Image, str string, pos geom.Vec2, clr color.Color) {
 	op := &text.DrawOptions{}
-	op.GeoM.Translate(pos.x, pos.y)
+	op.GeoM.Translate(pos.X, pos.Y)
 	op.ColorScale.ScaleWithColor(clr)
 	text.Draw(dst, str, mplusNormalFace, op)
 }
+
+func MeasureText(str string) float64 {
+	return text.Advance(str, mplusNormalFace)
+}
@@ -0,0 +1,182 @@
+// Package svg exports sketches as SVG drawings in world units
+package svg
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+
+	"unholy-cad/geom"
+	"unholy-cad/sketch"
+	"unholy-cad/view"
+)
+
+const (
+	// size in pixels the annotations are laid out for when no scale is given
+	defaultSize = 800.0
+	// space around the geometry for the dimensions, in pixels
+	margin = 60.0
+
+	fontFamily = "M PLUS 1p, sans-serif"
+)
+
+// Options select what is exported besides the geometry
+type Options struct {
+	view.Options
+	// pixels per world unit the annotations are laid out at. Arrows, glyphs and text keep
+	// their size in pixels, so a larger scale makes them smaller next to the geometry.
+	// Zero fits the sketch into about 800 pixels
+	Scale float64
+}
+
+// DefaultOptions exports the geometry with its constraints
+var DefaultOptions = Options{Options: view.Options{Constraints: true}}
+
+// Export draws a sketch as an SVG document. Coordinates are world units, which the
+// document maps to millimeters
+func Export(s *sketch.Sketch, options Options) ([]byte, error) {
+	lower, upper := view.Bounds(s)
+
+	scale := options.Scale
+	if scale <= 0 {
+		scale = defaultSize / math.Max(upper.X-lower.X, upper.Y-lower.Y)
+		if math.IsInf(scale, 0) || math.IsNaN(scale) {
+			scale = 20
+		}
+	}
+	offset := geom.Vec2{X: margin / scale, Y: margin / scale}
+	lower = lower.Sub(offset)
+	upper = upper.Add(offset)
+	size := upper.Sub(lower)
+
+	face, err := view.NewFace(view.FontSize)
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+
+	canvas := &Canvas{
+		Camera: view.Camera{Position: lower, Scale: scale},
+		face:   face,
+	}
+	r := view.NewRenderer(s, canvas.Camera, size.X*scale, size.Y*scale)
+	r.Options = options.Options
+	if dof, err := s.AnalyzeDegreesOfFreedom(); err == nil {
+		r.Dof = dof
+	}
+	// elements that cannot be drawn are left out like in the editor
+	r.Draw(canvas)
+
+	out := &bytes.Buffer{}
+	fmt.Fprintf(out, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(out, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%smm\" height=\"%smm\" viewBox=\"%s %s %s %s\">\n",
+		number(size.X), number(size.Y), number(lower.X), number(lower.Y), number(size.X), number(size.Y))
+	fmt.Fprintf(out, "<rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\" fill=\"#ffffff\"/>\n",
+		number(lower.X), number(lower.Y), number(size.X), number(size.Y))
+	out.Write(canvas.body.Bytes())
+	fmt.Fprintf(out, "</svg>\n")
+	return out.Bytes(), nil
+}
+
+// Canvas collects SVG elements. It is drawn on in pixels like the screen and writes the
+// elements back in world units through its camera
+type Canvas struct {
+	Camera view.Camera
+	face   font.Face
+	body   bytes.Buffer
+}
+
+func (c *Canvas) world(p geom.Vec2) geom.Vec2 {
+	return c.Camera.InverseTransformPoint(p)
+}
+
+func (c *Canvas) StrokeLine(p1, p2 geom.Vec2, thickness float64, col color.Color) {
+	p1, p2 = c.world(p1), c.world(p2)
+	fmt.Fprintf(&c.body, "<line x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\" %s/>\n",
+		number(p1.X), number(p1.Y), number(p2.X), number(p2.Y), c.stroke(thickness, col))
+}
+
+func (c *Canvas) StrokeCircle(center geom.Vec2, radius, thickness float64, col color.Color) {
+	center = c.world(center)
+	fmt.Fprintf(&c.body, "<circle cx=\"%s\" cy=\"%s\" r=\"%s\" fill=\"none\" %s/>\n",
+		number(center.X), number(center.Y), number(radius/c.Camera.Scale), c.stroke(thickness, col))
+}
+
+func (c *Canvas) StrokeArc(center geom.Vec2, radius, startAngle, sweep, thickness float64, col color.Color) {
+	if math.Abs(sweep) >= 2*math.Pi {
+		c.StrokeCircle(center, radius, thickness, col)
+		return
+	}
+	center = c.world(center)
+	radius /= c.Camera.Scale
+	start := center.Add(geom.Vec2{X: math.Cos(startAngle), Y: math.Sin(startAngle)}.Mul(radius))
+	end := center.Add(geom.Vec2{X: math.Cos(startAngle + sweep), Y: math.Sin(startAngle + sweep)}.Mul(radius))
+	largeArc, sweepFlag := 0, 0
+	if math.Abs(sweep) > math.Pi {
+		largeArc = 1
+	}
+	if sweep > 0 {
+		sweepFlag = 1
+	}
+	fmt.Fprintf(&c.body, "<path d=\"M %s %s A %s %s 0 %d %d %s %s\" fill=\"none\" %s/>\n",
+		number(start.X), number(start.Y), number(radius), number(radius), largeArc, sweepFlag,
+		number(end.X), number(end.Y), c.stroke(thickness, col))
+}
+
+func (c *Canvas) FillPolygons(polygons [][]geom.Vec2, col color.Color) {
+	var path strings.Builder
+	for _, polygon := range polygons {
+		for i, p := range polygon {
+			p = c.world(p)
+			command := "L"
+			if i == 0 {
+				command = "M"
+			}
+			fmt.Fprintf(&path, "%s %s %s ", command, number(p.X), number(p.Y))
+		}
+		path.WriteString("Z ")
+	}
+	fmt.Fprintf(&c.body, "<path d=\"%s\" fill-rule=\"nonzero\" %s/>\n", strings.TrimSpace(path.String()), paint("fill", col))
+}
+
+func (c *Canvas) DrawText(text string, position geom.Vec2, col color.Color) {
+	// svg places text on its baseline
+	baseline := c.world(position.Add(geom.Vec2{Y: float64(c.face.Metrics().Ascent.Round())}))
+	fmt.Fprintf(&c.body, "<text x=\"%s\" y=\"%s\" font-family=\"%s\" font-size=\"%s\" %s>%s</text>\n",
+		number(baseline.X), number(baseline.Y), fontFamily, number(view.FontSize/c.Camera.Scale),
+		paint("fill", col), html.EscapeString(text))
+}
+
+func (c *Canvas) MeasureText(text string) float64 {
+	return float64(font.MeasureString(c.face, text).Round())
+}
+
+// stroke returns the stroke attributes of an outline with a thickness in pixels
+func (c *Canvas) stroke(thickness float64, col color.Color) string {
+	return paint("stroke", col) + " stroke-width=\"" + number(thickness/c.Camera.Scale) + "\" stroke-linecap=\"round\""
+}
+
+// paint returns a fill or stroke attribute, with an opacity when the color is translucent
+func paint(attribute string, col color.Color) string {
+	r, g, b, a := col.RGBA()
+	if a == 0 {
+		return attribute + "=\"none\""
+	}
+	// colors are premultiplied
+	value := fmt.Sprintf("%s=\"#%02x%02x%02x\"", attribute, r*0xff/a, g*0xff/a, b*0xff/a)
+	if a < 0xffff {
+		value += fmt.Sprintf(" %s-opacity=\"%s\"", attribute, number(float64(a)/0xffff))
+	}
+	return value
+}
+
+// number formats a coordinate without trailing zeros
+func number(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e4)/1e4, 'f', -1, 64)
+}
@@ -0,0 +1,344 @@
+//go:build !headless
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"unholy-cad/geom"
+	"unholy-cad/sketch"
+)
+
+type Tool int
+
+const (
+	ToolSelect Tool = iota
+	ToolPoint
+	ToolLine
+	ToolPolyline
+	ToolCircle
+	ToolArc
+)
+
+type toolDefinition struct {
+	tool   Tool
+	name   string
+	hotkey ebiten.Key
+}
+
+var tools = []toolDefinition{
+	{ToolSelect, "Select", ebiten.KeyDigit1},
+	{ToolPoint, "Point", ebiten.KeyDigit2},
+	{ToolLine, "Line", ebiten.KeyDigit3},
+	{ToolPolyline, "Polyline", ebiten.KeyDigit4},
+	{ToolCircle, "Circle", ebiten.KeyDigit5},
+	{ToolArc, "Arc", ebiten.KeyDigit6},
+}
+
+const (
+	toolbarY       = 10.0
+	toolbarHeight  = 22.0
+	toolbarPadding = 6.0
+)
+
+// pendingVertex is the first point of a line that has not been placed yet. It either
+// refers to an existing point or holds a world position for a point still to be created
+type pendingVertex struct {
+	active   bool
+	pointId  int
+	position geom.Vec2
+}
+
+func toolLabel(t toolDefinition) string {
+	return fmt.Sprintf("%d %s", t.hotkey-ebiten.KeyDigit0, t.name)
+}
+
+// toolbarButtons returns the screen rectangle of every toolbar button, right aligned
+func toolbarButtons() []struct{ min, max geom.Vec2 } {
+	buttons := make([]struct{ min, max geom.Vec2 }, len(tools))
+	x := float64(screenWidth) - 10
+	for i := len(tools) - 1; i >= 0; i-- {
+		width := MeasureText(toolLabel(tools[i])) + 2*toolbarPadding
+		x -= width
+		buttons[i].min = geom.Vec2{X: x, Y: toolbarY}
+		buttons[i].max = geom.Vec2{X: x + width, Y: toolbarY + toolbarHeight}
+		x -= 4
+	}
+	return buttons
+}
+
+// toolbarHit returns the index of the toolbar button under a screen position, or -1
+func toolbarHit(screenPos geom.Vec2) int {
+	for i, button := range toolbarButtons() {
+		if screenPos.X >= button.min.X && screenPos.X <= button.max.X && screenPos.Y >= button.min.Y && screenPos.Y <= button.max.Y {
+			return i
+		}
+	}
+	return -1
+}
+
+// resolvePending returns the world position of a pending vertex, following its point id
+// when it refers to an existing point
+func (g *Game) resolvePending(v pendingVertex) (geom.Vec2, bool) {
+	if v.pointId == noElement {
+		return v.position, true
+	}
+	point, err := sketch.GetElementByID[*sketch.SketchPoint](&g.sketch, v.pointId)
+	if err != nil {
+		return geom.Vec2{}, false
+	}
+	return point.Position, true
+}
+
+// hoverPosition returns the position of the hovered point, or the world position under the cursor
+func (g *Game) hoverPosition(world geom.Vec2) geom.Vec2 {
+	if g.hoverId != noElement {
+		if point, err := sketch.GetElementByID[*sketch.SketchPoint](&g.sketch, g.hoverId); err == nil {
+			return point.Position
+		}
+	}
+	return world
+}
+
+func (g *Game) setTool(tool Tool) {
+	g.tool = tool
+	g.pending = pendingVertex{}
+	g.pendingArcStart = pendingVertex{}
+	g.selection = nil
+	g.drag.active = false
+}
+
+// updateTools switches tools from hotkeys and the toolbar, and runs the active tool.
+// mouse is the cursor position on screen
+func (g *Game) updateTools(mouse geom.Vec2) {
+	for _, t := range tools {
+		if inpututil.IsKeyJustPressed(t.hotkey) {
+			g.setTool(t.tool)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) || inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		// finishes a polyline, or drops the start of a line that was not placed yet
+		g.pending = pendingVertex{}
+		g.pendingArcStart = pendingVertex{}
+		g.selection = nil
+	}
+
+	clicked := inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft)
+	if clicked {
+		if i := toolbarHit(mouse); i >= 0 {
+			g.setTool(tools[i].tool)
+			return
+		}
+	}
+
+	if g.tool == ToolSelect {
+		g.updateDrag(mouse, clicked)
+		return
+	}
+
+	// creation tools snap to existing points
+	g.hoverId = g.hitTestPoint(mouse)
+	if !clicked {
+		return
+	}
+
+	world := g.camera.InverseTransformPoint(mouse)
+	switch g.tool {
+	case ToolPoint:
+		if g.hoverId == noElement {
+			g.edit("Add point", func() {
+				g.sketch.AddPoint(world)
+			})
+		}
+	case ToolLine, ToolPolyline:
+		g.placeLineVertex(world)
+	case ToolCircle:
+		g.placeCircleVertex(world)
+	case ToolArc:
+		g.placeArcVertex(world)
+	}
+}
+
+// placeCircleVertex handles a click of the circle tool, the first click places the
+// center and the second one sets the radius
+func (g *Game) placeCircleVertex(world geom.Vec2) {
+	if !g.pending.active {
+		g.pending = pendingVertex{active: true, pointId: g.hoverId, position: world}
+		return
+	}
+
+	center, ok := g.resolvePending(g.pending)
+	if !ok {
+		g.pending = pendingVertex{}
+		return
+	}
+	radius := center.DistanceTo(g.hoverPosition(world))
+	if radius == 0 {
+		return
+	}
+	centerId := g.pending.pointId
+	g.edit("Add circle", func() {
+		if centerId == noElement {
+			centerId = g.sketch.AddPoint(center).Id
+		}
+		g.sketch.AddCircle(centerId, radius)
+	})
+	g.pending = pendingVertex{}
+}
+
+// placeArcVertex handles a click of the arc tool. The clicks place the center, the start
+// point and the end point, a new end point is put on the radius of the start point
+func (g *Game) placeArcVertex(world geom.Vec2) {
+	if !g.pending.active {
+		g.pending = pendingVertex{active: true, pointId: g.hoverId, position: world}
+		return
+	}
+	center, ok := g.resolvePending(g.pending)
+	if !ok {
+		g.pending = pendingVertex{}
+		g.pendingArcStart = pendingVertex{}
+		return
+	}
+	if !g.pendingArcStart.active {
+		if (g.hoverId != noElement && g.hoverId == g.pending.pointId) || center.DistanceTo(g.hoverPosition(world)) == 0 {
+			return
+		}
+		g.pendingArcStart = pendingVertex{active: true, pointId: g.hoverId, position: world}
+		return
+	}
+
+	start, ok := g.resolvePending(g.pendingArcStart)
+	if !ok {
+		g.pendingArcStart = pendingVertex{}
+		return
+	}
+	endId := g.hoverId
+	if endId != noElement && (endId == g.pending.pointId || endId == g.pendingArcStart.pointId) {
+		return
+	}
+	direction := world.Sub(center)
+	if endId == noElement && direction.Magnitude() == 0 {
+		return
+	}
+
+	centerId := g.pending.pointId
+	startId := g.pendingArcStart.pointId
+	g.edit("Add arc", func() {
+		if centerId == noElement {
+			centerId = g.sketch.AddPoint(center).Id
+		}
+		if startId == noElement {
+			startId = g.sketch.AddPoint(start).Id
+		}
+		if endId == noElement {
+			endId = g.sketch.AddPoint(center.Add(direction.Normalize().Mul(center.DistanceTo(start)))).Id
+		}
+		arc := g.sketch.AddArc(centerId, startId, endId)
+		// an existing end point may not be on the radius yet
+		for _, constraint := range arc.GetImplicitConstraints() {
+			if satisfied, err := constraint.IsSatisfied(&g.sketch); err == nil && !satisfied {
+				g.solve()
+				break
+			}
+		}
+	})
+	g.pending = pendingVertex{}
+	g.pendingArcStart = pendingVertex{}
+}
+
+// placeLineVertex handles a click of the line and polyline tools. Clicking an existing
+// point reuses its id so connected lines share their endpoints
+func (g *Game) placeLineVertex(world geom.Vec2) {
+	if !g.pending.active {
+		g.pending = pendingVertex{active: true, pointId: g.hoverId, position: world}
+		return
+	}
+
+	startId := g.pending.pointId
+	endId := g.hoverId
+	if startId != noElement && startId == endId {
+		// zero length line
+		return
+	}
+	closesLoop := endId != noElement
+	g.edit("Add line", func() {
+		if startId == noElement {
+			startId = g.sketch.AddPoint(g.pending.position).Id
+		}
+		if endId == noElement {
+			endId = g.sketch.AddPoint(world).Id
+		}
+		g.sketch.AddLine(startId, endId)
+	})
+
+	if g.tool == ToolPolyline && !closesLoop {
+		g.pending = pendingVertex{active: true, pointId: endId}
+	} else {
+		g.pending = pendingVertex{}
+	}
+}
+
+// drawToolPreview draws the geometry that would be created by the next click
+func (g *Game) drawToolPreview(screen *ebiten.Image) {
+	if !g.pending.active {
+		return
+	}
+
+	start, ok := g.resolvePending(g.pending)
+	if !ok {
+		g.pending = pendingVertex{}
+		return
+	}
+
+	mouseX, mouseY := ebiten.CursorPosition()
+	end := g.hoverPosition(g.camera.InverseTransformPoint(geom.Vec2{X: float64(mouseX), Y: float64(mouseY)}))
+
+	r := g.renderer()
+	canvas := screenCanvas{screen}
+	previewColor := color.RGBA{0x33, 0x99, 0xff, 0x88}
+	switch g.tool {
+	case ToolCircle:
+		r.DrawConstructionLine(canvas, start, end, previewColor)
+		r.DrawCircle(canvas, start, start.DistanceTo(end)*g.camera.Scale, previewColor)
+	case ToolArc:
+		if !g.pendingArcStart.active {
+			r.DrawConstructionLine(canvas, start, end, previewColor)
+			return
+		}
+		arcStart, ok := g.resolvePending(g.pendingArcStart)
+		if !ok {
+			return
+		}
+		v1 := arcStart.Sub(start)
+		v2 := end.Sub(start)
+		startAngle := math.Atan2(v1.Y, v1.X)
+		sweep := math.Atan2(v2.Y, v2.X) - startAngle
+		if sweep < 0 {
+			sweep += 2 * math.Pi
+		}
+		r.DrawConstructionLine(canvas, start, arcStart, previewColor)
+		r.DrawArc(canvas, start, v1.Magnitude(), startAngle, sweep, 2, previewColor)
+	default:
+		r.DrawConstructionLine(canvas, start, end, previewColor)
+	}
+}
+
+func (g *Game) drawToolbar(screen *ebiten.Image) {
+	for i, button := range toolbarButtons() {
+		background := color.RGBA{0xEE, 0xEE, 0xEE, 0xFF}
+		foreground := color.RGBA{0x11, 0x11, 0x11, 0xFF}
+		if tools[i].tool == g.tool {
+			background = color.RGBA{0x33, 0x99, 0xff, 0xFF}
+			foreground = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+		}
+		size := button.max.Sub(button.min)
+		vector.DrawFilledRect(screen, float32(button.min.X), float32(button.min.Y), float32(size.X), float32(size.Y), background, false)
+		DrawText(screen, toolLabel(tools[i]), button.min.Add(geom.Vec2{X: toolbarPadding, Y: 2}), foreground)
+	}
+}
@@ -0,0 +1,103 @@
+package view
+
+import (
+	"image/color"
+	"math"
+
+	"unholy-cad/geom"
+	"unholy-cad/sketch"
+)
+
+// Canvas is a surface the sketch is drawn on. Positions and sizes are in pixels with
+// y pointing down, the same as on screen
+type Canvas interface {
+	StrokeLine(p1, p2 geom.Vec2, thickness float64, col color.Color)
+	StrokeCircle(center geom.Vec2, radius, thickness float64, col color.Color)
+	// StrokeArc draws the arc from the start angle over sweep radians, with angles
+	// increasing from the x axis towards the y axis
+	StrokeArc(center geom.Vec2, radius, startAngle, sweep, thickness float64, col color.Color)
+	// FillPolygons fills the polygons with the nonzero rule, so a polygon running the
+	// other way inside another one cuts a hole into it
+	FillPolygons(polygons [][]geom.Vec2, col color.Color)
+	// DrawText draws one line of text with its top left corner at the position
+	DrawText(text string, position geom.Vec2, col color.Color)
+	MeasureText(text string) float64
+}
+
+// Camera maps world positions to canvas pixels
+type Camera struct {
+	// world position at the top left corner of the canvas
+	Position geom.Vec2
+	// pixels per world unit
+	Scale float64
+	// round to whole pixels to avoid subpixel rendering on screen
+	PixelSnap bool
+}
+
+func (c *Camera) TransformPoint(p geom.Vec2) geom.Vec2 {
+	screen := geom.Vec2{
+		X: (p.X - c.Position.X) * c.Scale,
+		Y: (p.Y - c.Position.Y) * c.Scale,
+	}
+	if c.PixelSnap {
+		screen = geom.Vec2{X: math.Round(screen.X), Y: math.Round(screen.Y)}
+	}
+	return screen
+}
+
+// InverseTransformPoint converts a canvas position to world coordinates
+func (c *Camera) InverseTransformPoint(p geom.Vec2) geom.Vec2 {
+	return geom.Vec2{
+		X: p.X/c.Scale + c.Position.X,
+		Y: p.Y/c.Scale + c.Position.Y,
+	}
+}
+
+// FitCamera returns the camera that shows the whole sketch centered on a canvas, with a
+// margin in pixels on every side
+func FitCamera(s *sketch.Sketch, width, height, margin float64) Camera {
+	lower, upper := Bounds(s)
+	size := upper.Sub(lower)
+	scale := math.Min((width-2*margin)/size.X, (height-2*margin)/size.Y)
+	if math.IsInf(scale, 0) || math.IsNaN(scale) || scale <= 0 {
+		scale = 20
+	}
+	middle := lower.Lerp(upper, 0.5)
+	return Camera{
+		Position: middle.Sub(geom.Vec2{X: width / 2, Y: height / 2}.Mul(1 / scale)),
+		Scale:    scale,
+	}
+}
+
+// Bounds returns the corners of the box around the geometry, or around the origin for
+// an empty sketch
+func Bounds(s *sketch.Sketch) (geom.Vec2, geom.Vec2) {
+	lower := geom.Vec2{X: math.Inf(1), Y: math.Inf(1)}
+	upper := geom.Vec2{X: math.Inf(-1), Y: math.Inf(-1)}
+	include := func(p geom.Vec2, radius float64) {
+		lower = geom.Vec2{X: math.Min(lower.X, p.X-radius), Y: math.Min(lower.Y, p.Y-radius)}
+		upper = geom.Vec2{X: math.Max(upper.X, p.X+radius), Y: math.Max(upper.Y, p.Y+radius)}
+	}
+
+	for _, element := range s.Elements {
+		if sketch.IsBuiltin(element.GetId()) {
+			continue
+		}
+		switch e := element.(type) {
+		case *sketch.SketchPoint:
+			include(e.Position, 0)
+		case sketch.SketchCurve:
+			center, err := e.GetCenter(s)
+			if err != nil {
+				continue
+			}
+			if radius, err := e.GetRadius(s); err == nil {
+				include(center.Position, radius)
+			}
+		}
+	}
+	if math.IsInf(lower.X, 0) {
+		return geom.Vec2{}, geom.Vec2{}
+	}
+	return lower, upper
+}
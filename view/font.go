@@ -0,0 +1,34 @@
+package view
+
+import (
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/examples/resources/fonts"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+)
+
+// FontSize is the size in pixels of the text drawn by the editor
+const FontSize = 14
+
+var (
+	fontOnce sync.Once
+	fontData *opentype.Font
+	fontErr  error
+)
+
+// NewFace returns the font used for labels at a size in pixels, for canvases that are
+// not drawn by ebiten
+func NewFace(size float64) (font.Face, error) {
+	fontOnce.Do(func() {
+		fontData, fontErr = opentype.Parse(fonts.MPlus1pRegular_ttf)
+	})
+	if fontErr != nil {
+		return nil, fontErr
+	}
+	return opentype.NewFace(fontData, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingNone,
+	})
+}
@@ -0,0 +1,129 @@
+package view
+
+import (
+	"image/color"
+	"math"
+
+	"unholy-cad/geom"
+)
+
+const gridSize = 1
+
+var (
+	gridColor      = color.RGBA{0xee, 0xee, 0xee, 0xFF}
+	majorGridColor = color.RGBA{0xbb, 0xbb, 0xbb, 0xFF}
+)
+
+// DrawLine draws a line between two world positions
+func (r *Renderer) DrawLine(c Canvas, p1, p2 geom.Vec2, col color.Color, thickness float64) {
+	c.StrokeLine(r.Camera.TransformPoint(p1), r.Camera.TransformPoint(p2), thickness, col)
+}
+
+// drawArrow draws an arrow between two canvas positions with the head at p2
+func drawArrow(c Canvas, p1, p2 geom.Vec2, col color.Color) {
+	direction := p2.Sub(p1).Normalize()
+	tangent := direction.Tangent().Normalize()
+
+	headWidth := 4.0
+	headLength := 10.0
+
+	// Draw the line
+	c.StrokeLine(p1, p2, 1, col)
+
+	arrowHeadBase := p2.Sub(direction.Mul(headLength))
+	arrowHeadLeft := arrowHeadBase.Add(tangent.Mul(headWidth))
+	arrowHeadRight := arrowHeadBase.Sub(tangent.Mul(headWidth))
+
+	c.StrokeLine(p2, arrowHeadLeft, 1, col)
+	c.StrokeLine(p2, arrowHeadRight, 1, col)
+}
+
+// DrawCircle draws a circle of a fixed size in pixels around a world position
+func (r *Renderer) DrawCircle(c Canvas, p geom.Vec2, radius float64, col color.Color) {
+	c.StrokeCircle(r.Camera.TransformPoint(p), radius, 1, col)
+}
+
+// DrawArc draws an arc in world coordinates from the start angle over sweep radians
+func (r *Renderer) DrawArc(c Canvas, center geom.Vec2, radius, startAngle, sweep, thickness float64, col color.Color) {
+	c.StrokeArc(r.Camera.TransformPoint(center), radius*r.Camera.Scale, startAngle, sweep, thickness, col)
+}
+
+// DrawConstructionLine draws a dashed line between two world positions
+func (r *Renderer) DrawConstructionLine(c Canvas, p1, p2 geom.Vec2, col color.Color) {
+	p1 = r.Camera.TransformPoint(p1)
+	p2 = r.Camera.TransformPoint(p2)
+
+	// Dashed line parameters
+	dashLength := 10.0
+	spaceLength := 10.0
+
+	// Calculate the total distance between the points
+	totalDistance := p1.DistanceTo(p2)
+
+	// Calculate the unit vector in the direction of the line
+	unitVector := geom.Vec2{
+		X: (p2.X - p1.X) / totalDistance,
+		Y: (p2.Y - p1.Y) / totalDistance,
+	}
+
+	// Iterate over the total distance, drawing dashes and leaving spaces
+	for distance := 0.0; distance < totalDistance; distance += dashLength + spaceLength {
+		start := geom.Vec2{
+			X: p1.X + unitVector.X*distance,
+			Y: p1.Y + unitVector.Y*distance,
+		}
+		end := geom.Vec2{
+			X: p1.X + unitVector.X*math.Min(distance+dashLength, totalDistance),
+			Y: p1.Y + unitVector.Y*math.Min(distance+dashLength, totalDistance),
+		}
+		c.StrokeLine(start, end, 2, col)
+	}
+}
+
+// drawGlyph draws a boxed label just below and right of a canvas position
+func drawGlyph(c Canvas, at geom.Vec2, label string, col color.Color) {
+	box := geom.Vec2{X: math.Max(14, c.MeasureText(label)+4), Y: 16}
+	topLeft := at.Add(geom.Vec2{X: 6, Y: 6})
+	c.StrokeLine(topLeft, topLeft.Add(geom.Vec2{X: box.X}), 1, col)
+	c.StrokeLine(topLeft.Add(geom.Vec2{X: box.X}), topLeft.Add(box), 1, col)
+	c.StrokeLine(topLeft.Add(box), topLeft.Add(geom.Vec2{Y: box.Y}), 1, col)
+	c.StrokeLine(topLeft.Add(geom.Vec2{Y: box.Y}), topLeft, 1, col)
+	c.DrawText(label, topLeft.Add(geom.Vec2{X: (box.X - c.MeasureText(label)) / 2}), col)
+}
+
+// drawGrid draws a line every world unit and a darker one every five
+func (r *Renderer) drawGrid(c Canvas) {
+	startX := int((r.Camera.Position.X)/gridSize)*gridSize - gridSize
+	endX := int((r.Camera.Position.X+r.Width/r.Camera.Scale)/gridSize)*gridSize + gridSize
+
+	startY := int((r.Camera.Position.Y)/gridSize)*gridSize - gridSize
+	endY := int((r.Camera.Position.Y+r.Height/r.Camera.Scale)/gridSize)*gridSize + gridSize
+
+	thickLineInterval := gridSize * 5
+
+	// Draw lighter grid lines first
+	for x := startX; x <= endX; x += gridSize {
+		if x%thickLineInterval != 0 {
+			r.DrawLine(c, geom.Vec2{X: float64(x), Y: float64(startY)}, geom.Vec2{X: float64(x), Y: float64(endY)}, gridColor, 1)
+		}
+	}
+
+	for y := startY; y <= endY; y += gridSize {
+		if y%thickLineInterval != 0 {
+			r.DrawLine(c, geom.Vec2{X: float64(startX), Y: float64(y)}, geom.Vec2{X: float64(endX), Y: float64(y)}, gridColor, 1)
+		}
+	}
+
+	// Draw darker grid lines on top
+	for x := startX; x <= endX; x += gridSize {
+		if x%thickLineInterval == 0 {
+			r.DrawLine(c, geom.Vec2{X: float64(x), Y: float64(startY)}, geom.Vec2{X: float64(x), Y: float64(endY)}, majorGridColor, 1)
+		}
+	}
+
+	for y := startY; y <= endY; y += gridSize {
+		if y%thickLineInterval == 0 {
+			r.DrawLine(c, geom.Vec2{X: float64(startX), Y: float64(y)}, geom.Vec2{X: float64(endX), Y: float64(y)}, majorGridColor, 1)
+		}
+	}
+}
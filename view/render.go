@@ -0,0 +1,733 @@
+package view
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"unholy-cad/geom"
+	"unholy-cad/sketch"
+)
+
+// NoElement is the id used when no element is hovered or edited
+const NoElement = math.MinInt32
+
+// LabelHeight is the height of a line of text in pixels
+const LabelHeight = 18.0
+
+func StateColor(state sketch.ConstraintState) color.Color {
+	switch state {
+	case sketch.FullyConstrained:
+		return color.RGBA{0x11, 0x11, 0x11, 0xFF}
+	case sketch.OverConstrained:
+		return color.RGBA{0xFF, 0x00, 0x00, 0xFF}
+	}
+	return color.RGBA{0x33, 0x99, 0xff, 0xFF}
+}
+
+var (
+	SelectionColor = color.RGBA{0xFF, 0x99, 0x00, 0xFF}
+	AxisColor      = color.RGBA{0xAA, 0xAA, 0xAA, 0xFF}
+	ReferenceColor = color.RGBA{0x88, 0x66, 0xBB, 0xFF}
+)
+
+// Options select what is drawn besides the sketch geometry
+type Options struct {
+	Grid bool
+	// constraint glyphs and dimensions
+	Constraints bool
+	// the origin and the axes
+	Construction bool
+}
+
+// DefaultOptions draws everything, like the editor
+var DefaultOptions = Options{Grid: true, Constraints: true, Construction: true}
+
+// Label is the canvas rectangle of a drawn dimension value
+type Label struct {
+	Id       int
+	Min, Max geom.Vec2
+}
+
+// Renderer draws a sketch onto a canvas. The highlight fields are set by the editor,
+// the zero values draw the sketch plainly
+type Renderer struct {
+	Sketch *sketch.Sketch
+	Camera Camera
+	// size of the canvas in pixels
+	Width, Height float64
+	Options       Options
+
+	Dof       sketch.DofAnalysis
+	HoverId   int
+	Selection []int
+	// constraints drawn as conflicting regardless of their state
+	Conflicts []int
+	// dimension whose label is left out because it is being edited
+	EditingId int
+	// closed regions are shaded and open ends marked when set
+	Topology *sketch.Topology
+
+	// Labels collects where the dimension values were drawn
+	Labels []Label
+}
+
+// NewRenderer returns a renderer without highlights that draws everything
+func NewRenderer(s *sketch.Sketch, camera Camera, width, height float64) *Renderer {
+	return &Renderer{
+		Sketch:    s,
+		Camera:    camera,
+		Width:     width,
+		Height:    height,
+		Options:   DefaultOptions,
+		HoverId:   NoElement,
+		EditingId: NoElement,
+	}
+}
+
+// Draw draws the grid and every element of the sketch. Elements that cannot be drawn
+// are skipped and returned as errors
+func (r *Renderer) Draw(canvas Canvas) []error {
+	r.Labels = r.Labels[:0]
+	if r.Options.Grid {
+		r.drawGrid(canvas)
+	}
+	if r.Topology != nil {
+		r.drawRegions(canvas)
+	}
+
+	errs := make([]error, 0)
+	for _, element := range r.Sketch.Elements {
+		if err := r.DrawElement(canvas, element); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if r.Topology != nil {
+		r.drawGaps(canvas)
+	}
+	return errs
+}
+
+func (r *Renderer) IsSelected(id int) bool {
+	for _, selected := range r.Selection {
+		if selected == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Renderer) isConflicting(id int) bool {
+	for _, conflict := range r.Conflicts {
+		if conflict == id {
+			return true
+		}
+	}
+	return false
+}
+
+// LineExtent returns the world positions a line is drawn and picked between. The axes
+// reach across the whole canvas
+func (r *Renderer) LineExtent(line *sketch.SketchLine) (geom.Vec2, geom.Vec2, error) {
+	startPoint, endPoint, err := sketch.GetLinePoints(r.Sketch, line.Id)
+	if err != nil {
+		return geom.Vec2{}, geom.Vec2{}, err
+	}
+	if !sketch.IsBuiltin(line.Id) {
+		return startPoint.Position, endPoint.Position, nil
+	}
+
+	// the axis through the visible circle around the middle of the canvas
+	topLeft := r.Camera.InverseTransformPoint(geom.Vec2{X: 0, Y: 0})
+	bottomRight := r.Camera.InverseTransformPoint(geom.Vec2{X: r.Width, Y: r.Height})
+	middle := topLeft.Lerp(bottomRight, 0.5)
+	reach := topLeft.DistanceTo(bottomRight) / 2
+
+	direction := endPoint.Position.Sub(startPoint.Position).Normalize()
+	along := middle.Sub(startPoint.Position).Dot(direction)
+	return startPoint.Position.Add(direction.Mul(along - reach)), startPoint.Position.Add(direction.Mul(along + reach)), nil
+}
+
+// drawDimensionLabel draws the value of a dimension and remembers where, so it can be
+// double clicked
+func (r *Renderer) drawDimensionLabel(canvas Canvas, d sketch.SketchDimension, label string, position geom.Vec2, col color.Color) {
+	if d.GetId() == r.EditingId {
+		return
+	}
+	canvas.DrawText(label, position, col)
+	r.Labels = append(r.Labels, Label{
+		Id:  d.GetId(),
+		Min: position,
+		Max: position.Add(geom.Vec2{X: canvas.MeasureText(label), Y: LabelHeight}),
+	})
+}
+
+// constraintColor is dark for satisfied constraints and red otherwise, or when they are
+// listed as conflicts. Reference dimensions have their own color
+func (r *Renderer) constraintColor(c sketch.SketchConstraint) (color.Color, error) {
+	if d, ok := c.(sketch.SketchDimension); ok && d.IsReference() {
+		return ReferenceColor, nil
+	}
+	if r.isConflicting(c.GetId()) {
+		return color.RGBA{0xFF, 0x00, 0x00, 0xFF}, nil
+	}
+	satisfied, err := c.IsSatisfied(r.Sketch)
+	if err != nil {
+		return nil, err
+	}
+	if !satisfied {
+		return color.RGBA{0xFF, 0x00, 0x00, 0xFF}, nil
+	}
+	return color.RGBA{0x11, 0x11, 0x11, 0xFF}, nil
+}
+
+// DrawElement draws one element unless the options leave it out
+func (r *Renderer) DrawElement(canvas Canvas, element sketch.SketchElement) error {
+	if _, ok := element.(sketch.SketchConstraint); ok && !r.Options.Constraints {
+		return nil
+	}
+	if sketch.IsBuiltin(element.GetId()) && !r.Options.Construction {
+		return nil
+	}
+
+	var err error
+	switch e := element.(type) {
+	case *sketch.SketchPoint:
+		r.drawSketchPoint(canvas, e)
+	case *sketch.SketchLine:
+		err = r.drawSketchLine(canvas, e)
+	case *sketch.SketchCircle:
+		err = r.drawSketchCircle(canvas, e)
+	case *sketch.SketchArc:
+		err = r.drawSketchArc(canvas, e)
+	case *sketch.SketchConstraintCornerAngle:
+		err = r.drawCornerAngle(canvas, e)
+	case *sketch.SketchConstraintLineLength:
+		err = r.drawLineLength(canvas, e)
+	case *sketch.SketchConstraintCoincident:
+		err = r.drawCoincident(canvas, e)
+	case *sketch.SketchConstraintHorizontal:
+		err = r.drawAlignment(canvas, e, e.Point1Id, e.Point2Id, "H")
+	case *sketch.SketchConstraintVertical:
+		err = r.drawAlignment(canvas, e, e.Point1Id, e.Point2Id, "V")
+	case *sketch.SketchConstraintParallel:
+		err = r.drawLinePairGlyph(canvas, e, e.Line1Id, e.Line2Id, "∥")
+	case *sketch.SketchConstraintPerpendicular:
+		err = r.drawLinePairGlyph(canvas, e, e.Line1Id, e.Line2Id, "⊥")
+	case *sketch.SketchConstraintLineAngle:
+		err = r.drawLineAngle(canvas, e)
+	case *sketch.SketchConstraintRadius:
+		err = r.drawRadius(canvas, e, e.CurveId, r.DimensionLabel("R=", e, "%.2f"), false)
+	case *sketch.SketchConstraintDiameter:
+		err = r.drawRadius(canvas, e, e.CurveId, r.DimensionLabel("Ø=", e, "%.2f"), true)
+	case *sketch.SketchConstraintTangent:
+		err = r.drawTangent(canvas, e)
+	case *sketch.SketchConstraintArcTangent:
+		err = r.drawArcTangent(canvas, e)
+	case *sketch.SketchConstraintConcentric:
+		err = r.drawConcentric(canvas, e)
+	case *sketch.SketchConstraintPointOnLine:
+		err = r.drawPointOn(canvas, e, e.PointId)
+	case *sketch.SketchConstraintPointOnCurve:
+		err = r.drawPointOn(canvas, e, e.PointId)
+	case *sketch.SketchConstraintSymmetric:
+		err = r.drawPointGlyphs(canvas, e, "S", e.Point1Id, e.Point2Id)
+	case *sketch.SketchConstraintMidpoint:
+		err = r.drawPointGlyphs(canvas, e, "M", e.PointId)
+	case *sketch.SketchConstraintEqual:
+		err = r.drawEqual(canvas, e)
+	case *sketch.SketchConstraintFixed:
+		err = r.drawFixed(canvas, e)
+	case *sketch.SketchConstraintPointDistance:
+		err = r.drawPointDistance(canvas, e)
+	case *sketch.SketchConstraintPointLineDistance:
+		err = r.drawPointLineDistance(canvas, e)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot draw %d: %w", element.GetId(), err)
+	}
+	return nil
+}
+
+func (r *Renderer) drawSketchLine(canvas Canvas, l *sketch.SketchLine) error {
+	start, end, err := r.LineExtent(l)
+	if err != nil {
+		return err
+	}
+
+	thickness, col := r.curveStyle(l.Id)
+	if sketch.IsBuiltin(l.Id) {
+		if !r.IsSelected(l.Id) {
+			col = AxisColor
+		}
+		r.DrawConstructionLine(canvas, start, end, col)
+		return nil
+	}
+	r.DrawLine(canvas, end, start, col, thickness)
+	return nil
+}
+
+// curveStyle returns the thickness and color of a line, circle or arc outline
+func (r *Renderer) curveStyle(id int) (float64, color.Color) {
+	thickness := 2.0
+	if id == r.HoverId {
+		thickness = 4
+	}
+	if r.IsSelected(id) {
+		return thickness, SelectionColor
+	}
+	return thickness, StateColor(r.Dof.GetElementState(id))
+}
+
+func (r *Renderer) drawSketchCircle(canvas Canvas, c *sketch.SketchCircle) error {
+	center, err := c.GetCenter(r.Sketch)
+	if err != nil {
+		return err
+	}
+	thickness, col := r.curveStyle(c.Id)
+	r.DrawArc(canvas, center.Position, c.Radius, 0, 2*math.Pi, thickness, col)
+	return nil
+}
+
+func (r *Renderer) drawSketchArc(canvas Canvas, a *sketch.SketchArc) error {
+	center, err := a.GetCenter(r.Sketch)
+	if err != nil {
+		return err
+	}
+	radius, err := a.GetRadius(r.Sketch)
+	if err != nil {
+		return err
+	}
+	start, sweep, err := a.GetAngles(r.Sketch)
+	if err != nil {
+		return err
+	}
+	thickness, col := r.curveStyle(a.Id)
+	r.DrawArc(canvas, center.Position, radius, start, sweep, thickness, col)
+	return nil
+}
+
+func (r *Renderer) drawSketchPoint(canvas Canvas, p *sketch.SketchPoint) {
+	radius := 3.0
+	if p.Id == r.HoverId {
+		radius = 5
+	}
+	col := StateColor(r.Dof.GetElementState(p.Id))
+	if sketch.IsBuiltin(p.Id) {
+		if p.Id != sketch.OriginId {
+			// the axis end points only give the axes their direction
+			return
+		}
+		col = AxisColor
+	}
+	if r.IsSelected(p.Id) {
+		col = SelectionColor
+	}
+	r.DrawCircle(canvas, p.Position, radius, col)
+}
+
+func (r *Renderer) drawCornerAngle(canvas Canvas, c *sketch.SketchConstraintCornerAngle) error {
+	col, err := r.constraintColor(c)
+	if err != nil {
+		return err
+	}
+
+	cornerPoint, linePoint1, linePoint2, err := c.GetPoints(r.Sketch)
+	if err != nil {
+		return err
+	}
+
+	// a right angle either way around gets the mark, clockwise ones are -90 or 270
+	if math.Abs(math.Remainder(c.Angle, 360)) == 90 && !c.IsReference() {
+		offset := 15.0
+
+		cp := r.Camera.TransformPoint(cornerPoint.Position)
+		point1 := r.Camera.TransformPoint(linePoint1.Position)
+		point2 := r.Camera.TransformPoint(linePoint2.Position)
+
+		o1 := point1.Sub(r.Camera.TransformPoint(cornerPoint.Position)).Normalize().Mul(offset)
+		o2 := point2.Sub(r.Camera.TransformPoint(cornerPoint.Position)).Normalize().Mul(offset)
+
+		canvas.StrokeLine(cp.Add(o1), cp.Add(o1).Add(o2), 1, col)
+		canvas.StrokeLine(cp.Add(o2), cp.Add(o1).Add(o2), 1, col)
+
+		// the mark has no text, it is the label that opens the editor
+		label := Label{Id: c.Id, Min: cp, Max: cp}
+		for _, corner := range []geom.Vec2{cp.Add(o1), cp.Add(o2), cp.Add(o1).Add(o2)} {
+			label.Min = geom.Vec2{X: math.Min(label.Min.X, corner.X), Y: math.Min(label.Min.Y, corner.Y)}
+			label.Max = geom.Vec2{X: math.Max(label.Max.X, corner.X), Y: math.Max(label.Max.Y, corner.Y)}
+		}
+		r.Labels = append(r.Labels, label)
+	} else {
+		center := r.Camera.TransformPoint(cornerPoint.Position)
+		radius := 20.0
+		// the arc turns from line1 to line2 the way the angle is measured, all the way
+		// around the outside for a reflex corner
+		v1 := linePoint1.Position.Sub(cornerPoint.Position)
+		startAngle := math.Atan2(v1.Y, v1.X)
+		current, err := c.GetCurrentAngle(r.Sketch)
+		if err != nil {
+			return err
+		}
+		sweep := current * math.Pi / 180
+		canvas.StrokeArc(center, radius, startAngle, sweep, 1, col)
+
+		midPointAngle := startAngle + sweep/2
+		mPoint := center.Add(geom.Vec2{X: math.Cos(midPointAngle), Y: math.Sin(midPointAngle)}.Mul(radius))
+
+		r.drawDimensionLabel(canvas, c, r.DimensionLabel("", c, "%.0f°"), mPoint, col)
+	}
+
+	return nil
+}
+
+func (r *Renderer) drawLineLength(canvas Canvas, c *sketch.SketchConstraintLineLength) error {
+	col, err := r.constraintColor(c)
+	if err != nil {
+		return err
+	}
+
+	startPoint, endPoint, err := sketch.GetLinePoints(r.Sketch, c.LineId)
+	if err != nil {
+		return err
+	}
+
+	r.drawDimension(canvas, c, startPoint.Position, endPoint.Position, r.DimensionLabel("L=", c, "%.2f"), col)
+	return nil
+}
+
+// drawDimension draws a linear dimension between two world positions: extension lines,
+// arrows pointing out from the middle and the label
+func (r *Renderer) drawDimension(canvas Canvas, d sketch.SketchDimension, start, end geom.Vec2, label string, col color.Color) {
+	startPosition := r.Camera.TransformPoint(start)
+	endPosition := r.Camera.TransformPoint(end)
+
+	direction := endPosition.Sub(startPosition).Normalize()
+	tangent := direction.Tangent()
+
+	offset := 12.0
+
+	canvas.StrokeLine(startPosition, startPosition.Add(tangent.Mul(offset+5)), 1, col)
+	canvas.StrokeLine(endPosition, endPosition.Add(tangent.Mul(offset+5)), 1, col)
+
+	startPosition = startPosition.Add(tangent)
+	endPosition = endPosition.Add(tangent)
+	midPoint := startPosition.Lerp(endPosition, 0.5).Add(tangent.Mul(offset))
+
+	drawArrow(canvas, midPoint, startPosition.Add(tangent.Mul(offset)).Add(direction.Mul(2.0)), col)
+	drawArrow(canvas, midPoint, endPosition.Add(tangent.Mul(offset)).Sub(direction.Mul(2.0)), col)
+
+	r.drawDimensionLabel(canvas, d, label, midPoint.Add(tangent.Mul(5)), col)
+}
+
+// drawPointDistance dimensions two points. Horizontal and vertical distances are measured
+// at the height or x position of the first point, with a dashed leader to the second
+func (r *Renderer) drawPointDistance(canvas Canvas, c *sketch.SketchConstraintPointDistance) error {
+	col, err := r.constraintColor(c)
+	if err != nil {
+		return err
+	}
+	points, err := sketch.GetPoints(r.Sketch, c.Point1Id, c.Point2Id)
+	if err != nil {
+		return err
+	}
+
+	start, end := points[0].Position, points[1].Position
+	prefix := "D="
+	switch c.Direction {
+	case sketch.DistanceHorizontal:
+		end = geom.Vec2{X: end.X, Y: start.Y}
+		prefix = "H="
+	case sketch.DistanceVertical:
+		end = geom.Vec2{X: start.X, Y: end.Y}
+		prefix = "V="
+	}
+	if end != points[1].Position {
+		r.DrawConstructionLine(canvas, end, points[1].Position, AxisColor)
+	}
+
+	r.drawDimension(canvas, c, start, end, r.DimensionLabel(prefix, c, "%.2f"), col)
+	return nil
+}
+
+// drawPointLineDistance dimensions a point to its perpendicular foot on the line
+func (r *Renderer) drawPointLineDistance(canvas Canvas, c *sketch.SketchConstraintPointLineDistance) error {
+	col, err := r.constraintColor(c)
+	if err != nil {
+		return err
+	}
+	point, foot, err := c.GetFoot(r.Sketch)
+	if err != nil {
+		return err
+	}
+
+	r.drawDimension(canvas, c, foot, point, r.DimensionLabel("D=", c, "%.2f"), col)
+	return nil
+}
+
+// drawCoincident draws a ring around the shared location
+func (r *Renderer) drawCoincident(canvas Canvas, c *sketch.SketchConstraintCoincident) error {
+	col, err := r.constraintColor(c)
+	if err != nil {
+		return err
+	}
+	points, err := sketch.GetPoints(r.Sketch, c.Point1Id, c.Point2Id)
+	if err != nil {
+		return err
+	}
+
+	r.DrawCircle(canvas, points[0].Position, 7, col)
+	if points[0].Position.DistanceTo(points[1].Position) > 0 {
+		r.DrawCircle(canvas, points[1].Position, 7, col)
+	}
+	return nil
+}
+
+// drawAlignment draws the label of a horizontal or vertical constraint next to the middle of its points
+func (r *Renderer) drawAlignment(canvas Canvas, c sketch.SketchConstraint, point1Id, point2Id int, label string) error {
+	col, err := r.constraintColor(c)
+	if err != nil {
+		return err
+	}
+	points, err := sketch.GetPoints(r.Sketch, point1Id, point2Id)
+	if err != nil {
+		return err
+	}
+
+	drawGlyph(canvas, r.Camera.TransformPoint(points[0].Position.Lerp(points[1].Position, 0.5)), label, col)
+	return nil
+}
+
+// drawLinePairGlyph draws the label of a parallel or perpendicular constraint next to both lines
+func (r *Renderer) drawLinePairGlyph(canvas Canvas, c sketch.SketchConstraint, line1Id, line2Id int, label string) error {
+	col, err := r.constraintColor(c)
+	if err != nil {
+		return err
+	}
+	for _, lineId := range []int{line1Id, line2Id} {
+		startPoint, endPoint, err := sketch.GetLinePoints(r.Sketch, lineId)
+		if err != nil {
+			return err
+		}
+		drawGlyph(canvas, r.Camera.TransformPoint(startPoint.Position.Lerp(endPoint.Position, 0.5)), label, col)
+	}
+	return nil
+}
+
+// drawLineAngle draws the angle halfway between the two lines with leaders to both of them
+func (r *Renderer) drawLineAngle(canvas Canvas, c *sketch.SketchConstraintLineAngle) error {
+	col, err := r.constraintColor(c)
+	if err != nil {
+		return err
+	}
+	midPoints := make([]geom.Vec2, 2)
+	for i, lineId := range []int{c.Line1Id, c.Line2Id} {
+		startPoint, endPoint, err := sketch.GetLinePoints(r.Sketch, lineId)
+		if err != nil {
+			return err
+		}
+		midPoints[i] = r.Camera.TransformPoint(startPoint.Position.Lerp(endPoint.Position, 0.5))
+	}
+
+	label := r.DimensionLabel("∠", c, "%.0f°")
+	center := midPoints[0].Lerp(midPoints[1], 0.5)
+	drawArrow(canvas, center, midPoints[0], col)
+	drawArrow(canvas, center, midPoints[1], col)
+	r.drawDimensionLabel(canvas, c, label, center.Add(geom.Vec2{X: -canvas.MeasureText(label) / 2, Y: 4}), col)
+	return nil
+}
+
+// drawTangent draws a glyph where the line touches the curve
+func (r *Renderer) drawTangent(canvas Canvas, c *sketch.SketchConstraintTangent) error {
+	col, err := r.constraintColor(c)
+	if err != nil {
+		return err
+	}
+	touch, err := c.GetTouchPoint(r.Sketch)
+	if err != nil {
+		return err
+	}
+	drawGlyph(canvas, r.Camera.TransformPoint(touch), "T", col)
+	return nil
+}
+
+// drawArcTangent draws a glyph at the point the arcs share
+func (r *Renderer) drawArcTangent(canvas Canvas, c *sketch.SketchConstraintArcTangent) error {
+	col, err := r.constraintColor(c)
+	if err != nil {
+		return err
+	}
+	_, _, sharedId, err := c.GetArcs(r.Sketch)
+	if err != nil {
+		return err
+	}
+	shared, err := sketch.GetElementByID[*sketch.SketchPoint](r.Sketch, sharedId)
+	if err != nil {
+		return err
+	}
+	drawGlyph(canvas, r.Camera.TransformPoint(shared.Position), "T", col)
+	return nil
+}
+
+// drawConcentric draws two rings around the first center
+func (r *Renderer) drawConcentric(canvas Canvas, c *sketch.SketchConstraintConcentric) error {
+	col, err := r.constraintColor(c)
+	if err != nil {
+		return err
+	}
+	center1, _, err := c.GetCenters(r.Sketch)
+	if err != nil {
+		return err
+	}
+	r.DrawCircle(canvas, center1.Position, 6, col)
+	r.DrawCircle(canvas, center1.Position, 10, col)
+	return nil
+}
+
+// drawPointOn draws a small square around a point that is held on a line or a curve
+func (r *Renderer) drawPointOn(canvas Canvas, c sketch.SketchConstraint, pointId int) error {
+	col, err := r.constraintColor(c)
+	if err != nil {
+		return err
+	}
+	point, err := sketch.GetElementByID[*sketch.SketchPoint](r.Sketch, pointId)
+	if err != nil {
+		return err
+	}
+	p := r.Camera.TransformPoint(point.Position)
+	size := 6.0
+	corners := []geom.Vec2{{X: -size, Y: -size}, {X: size, Y: -size}, {X: size, Y: size}, {X: -size, Y: size}}
+	for i := range corners {
+		canvas.StrokeLine(p.Add(corners[i]), p.Add(corners[(i+1)%len(corners)]), 1, col)
+	}
+	return nil
+}
+
+// drawPointGlyphs draws the same glyph next to each of the points
+func (r *Renderer) drawPointGlyphs(canvas Canvas, c sketch.SketchConstraint, label string, pointIds ...int) error {
+	col, err := r.constraintColor(c)
+	if err != nil {
+		return err
+	}
+	points, err := sketch.GetPoints(r.Sketch, pointIds...)
+	if err != nil {
+		return err
+	}
+	for _, point := range points {
+		drawGlyph(canvas, r.Camera.TransformPoint(point.Position), label, col)
+	}
+	return nil
+}
+
+// drawEqual draws a glyph at the middle of both lines or at the top of both curves
+func (r *Renderer) drawEqual(canvas Canvas, c *sketch.SketchConstraintEqual) error {
+	col, err := r.constraintColor(c)
+	if err != nil {
+		return err
+	}
+	for _, id := range []int{c.Element1Id, c.Element2Id} {
+		var at geom.Vec2
+		if curve, err := sketch.GetCurve(r.Sketch, id); err == nil {
+			center, err := curve.GetCenter(r.Sketch)
+			if err != nil {
+				return err
+			}
+			radius, err := curve.GetRadius(r.Sketch)
+			if err != nil {
+				return err
+			}
+			at = center.Position.Sub(geom.Vec2{Y: radius})
+		} else {
+			startPoint, endPoint, err := sketch.GetLinePoints(r.Sketch, id)
+			if err != nil {
+				return err
+			}
+			at = startPoint.Position.Lerp(endPoint.Position, 0.5)
+		}
+		drawGlyph(canvas, r.Camera.TransformPoint(at), "=", col)
+	}
+	return nil
+}
+
+// drawFixed draws a ground symbol under the fixed point
+func (r *Renderer) drawFixed(canvas Canvas, c *sketch.SketchConstraintFixed) error {
+	col, err := r.constraintColor(c)
+	if err != nil {
+		return err
+	}
+	point, err := sketch.GetElementByID[*sketch.SketchPoint](r.Sketch, c.PointId)
+	if err != nil {
+		return err
+	}
+	p := r.Camera.TransformPoint(point.Position)
+	base := p.Add(geom.Vec2{Y: 8})
+	canvas.StrokeLine(p, base, 1, col)
+	canvas.StrokeLine(base.Sub(geom.Vec2{X: 7}), base.Add(geom.Vec2{X: 7}), 1, col)
+	for x := -6.0; x <= 6; x += 4 {
+		tick := base.Add(geom.Vec2{X: x})
+		canvas.StrokeLine(tick, tick.Add(geom.Vec2{X: -3, Y: 4}), 1, col)
+	}
+	return nil
+}
+
+// drawRadius draws a radius or diameter dimension. Arcs are dimensioned through the middle
+// of the arc, circles towards the upper right
+func (r *Renderer) drawRadius(canvas Canvas, c sketch.SketchDimension, curveId int, label string, diameter bool) error {
+	col, err := r.constraintColor(c)
+	if err != nil {
+		return err
+	}
+	curve, err := sketch.GetCurve(r.Sketch, curveId)
+	if err != nil {
+		return err
+	}
+	center, err := curve.GetCenter(r.Sketch)
+	if err != nil {
+		return err
+	}
+	radius, err := curve.GetRadius(r.Sketch)
+	if err != nil {
+		return err
+	}
+
+	angle := -math.Pi / 4
+	if arc, ok := curve.(*sketch.SketchArc); ok {
+		start, sweep, err := arc.GetAngles(r.Sketch)
+		if err != nil {
+			return err
+		}
+		angle = start + sweep/2
+	}
+	direction := geom.Vec2{X: math.Cos(angle), Y: math.Sin(angle)}
+
+	centerPosition := r.Camera.TransformPoint(center.Position)
+	rim := centerPosition.Add(direction.Mul(radius * r.Camera.Scale))
+	drawArrow(canvas, centerPosition, rim, col)
+	if diameter {
+		drawArrow(canvas, centerPosition, centerPosition.Sub(direction.Mul(radius*r.Camera.Scale)), col)
+	}
+
+	r.drawDimensionLabel(canvas, c, label, rim.Add(direction.Mul(8)).Sub(geom.Vec2{Y: 8}), col)
+	return nil
+}
+
+// DimensionLabel formats the value of a dimension, with the expression in front when the
+// value is driven by one. Reference dimensions show the measured value in parentheses
+func (r *Renderer) DimensionLabel(prefix string, d sketch.SketchDimension, format string) string {
+	if d.IsReference() {
+		measured, err := d.GetMeasuredValue(r.Sketch)
+		if err != nil {
+			return "(" + prefix + "?)"
+		}
+		return "(" + prefix + fmt.Sprintf(format, measured) + ")"
+	}
+
+	value := fmt.Sprintf(format, d.GetValue())
+	expression := strings.TrimSpace(d.GetExpression())
+	if _, err := strconv.ParseFloat(expression, 64); expression == "" || err == nil {
+		return prefix + value
+	}
+	return prefix + expression + " = " + value
+}
@@ -0,0 +1,51 @@
+package view
+
+import (
+	"image/color"
+
+	"unholy-cad/geom"
+	"unholy-cad/sketch"
+)
+
+var (
+	regionColor = color.NRGBA{0x33, 0x99, 0xff, 0x28}
+	gapColor    = color.RGBA{0xFF, 0x00, 0x00, 0xFF}
+)
+
+// drawRegions shades the closed regions, leaving their holes empty
+func (r *Renderer) drawRegions(canvas Canvas) {
+	for _, region := range r.Topology.Regions {
+		polygons := make([][]geom.Vec2, 0, 1+len(region.Holes))
+		for _, loop := range append([]sketch.Loop{region.Outer}, region.Holes...) {
+			points, err := loop.Points(r.Sketch)
+			if err != nil {
+				// the region is out of date, the next analysis drops it
+				return
+			}
+			for i, p := range points {
+				points[i] = r.Camera.TransformPoint(p)
+			}
+			polygons = append(polygons, points)
+		}
+		canvas.FillPolygons(polygons, regionColor)
+	}
+}
+
+// drawGaps circles the open ends in red and connects the ones that are likely meant to meet
+func (r *Renderer) drawGaps(canvas Canvas) {
+	for _, id := range r.Topology.Dangling {
+		point, err := sketch.GetElementByID[*sketch.SketchPoint](r.Sketch, id)
+		if err != nil {
+			continue
+		}
+		r.DrawCircle(canvas, point.Position, 7, gapColor)
+	}
+	for _, gap := range r.Topology.Gaps {
+		points, err := sketch.GetPoints(r.Sketch, gap[0], gap[1])
+		if err != nil {
+			// the topology is out of date, the next analysis drops the gap
+			continue
+		}
+		r.DrawConstructionLine(canvas, points[0].Position, points[1].Position, gapColor)
+	}
+}